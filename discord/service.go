@@ -8,10 +8,43 @@ import (
 	"net/http"
 )
 
+const apiURL = "https://discord.com/api/v10"
+
+type Client interface {
+	GetUserServers(ctx context.Context) ([]*Server, error)
+	GetUserServerProfile(ctx context.Context, serverID string) (*ServerMember, error)
+}
+
+type client struct {
+	http *http.Client
+}
+
+// NewClient uses an authenticated client, such as the refreshing one returned
+// by oauth.OAuth.Client, instead of a fixed access token.
+func NewClient(httpClient *http.Client) Client {
+	return &client{http: httpClient}
+}
+
+func (c *client) GetUserServers(ctx context.Context) ([]*Server, error) {
+	return getUserServers(ctx, c.http, "")
+}
+
+func (c *client) GetUserServerProfile(ctx context.Context, serverID string) (*ServerMember, error) {
+	return getUserServerProfile(ctx, c.http, serverID, "")
+}
+
 func GetUserServes(ctx context.Context, token string) ([]*Server, error) {
-	url := "https://discord.com/api/v10/users/@me/guilds"
+	return getUserServers(ctx, &http.Client{}, token)
+}
+
+func GetUserServerProfile(ctx context.Context, serverID string, token string) (*ServerMember, error) {
+	return getUserServerProfile(ctx, &http.Client{}, serverID, token)
+}
+
+func getUserServers(ctx context.Context, client *http.Client, token string) ([]*Server, error) {
+	url := apiURL + "/users/@me/guilds"
 
-	body, err := makeRequest(ctx, url, token)
+	body, err := makeRequest(ctx, client, url, token)
 	if err != nil {
 		return nil, err
 	}
@@ -24,10 +57,10 @@ func GetUserServes(ctx context.Context, token string) ([]*Server, error) {
 	return servers, nil
 }
 
-func GetUserServerProfile(ctx context.Context, serverID string, token string) (*ServerMember, error) {
-	url := fmt.Sprintf("https://discord.com/api/v10/users/@me/guilds/%s/member", serverID)
+func getUserServerProfile(ctx context.Context, client *http.Client, serverID string, token string) (*ServerMember, error) {
+	url := fmt.Sprintf("%s/users/@me/guilds/%s/member", apiURL, serverID)
 
-	body, err := makeRequest(ctx, url, token)
+	body, err := makeRequest(ctx, client, url, token)
 	if err != nil {
 		return nil, err
 	}
@@ -40,15 +73,15 @@ func GetUserServerProfile(ctx context.Context, serverID string, token string) (*
 	return member, nil
 }
 
-func makeRequest(ctx context.Context, url string, token string) ([]byte, error) {
-	client := &http.Client{}
-
+func makeRequest(ctx context.Context, client *http.Client, url string, token string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
@@ -56,5 +89,9 @@ func makeRequest(ctx context.Context, url string, token string) ([]byte, error)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid response: %s", res.Status)
+	}
+
 	return io.ReadAll(res.Body)
 }
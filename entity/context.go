@@ -0,0 +1,14 @@
+package entity
+
+import "context"
+
+type userKey struct{}
+
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok && userID != ""
+}
@@ -0,0 +1,28 @@
+package lock
+
+import (
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Config struct {
+	Collection    string
+	Owner         string
+	TTL           time.Duration
+	RetryInterval time.Duration
+	AutoRenew     bool
+}
+
+func NewConfig() Config {
+	hostname, _ := os.Hostname()
+
+	return Config{
+		Collection:    "locks",
+		Owner:         hostname + "-" + uuid.NewString(),
+		TTL:           30 * time.Second,
+		RetryInterval: time.Second,
+		AutoRenew:     true,
+	}
+}
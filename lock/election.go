@@ -0,0 +1,49 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+func Lead(ctx context.Context, locker Locker, name string, callbacks Callbacks) error {
+	for {
+		lease, err := locker.Acquire(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		// Without automatic renewal nothing would close Lost, so the leader
+		// keeps its own lease alive.
+		if interval := time.Until(lease.Expires()) / 3; !lease.renewing && interval > 0 {
+			go keepAlive(locker, lease, interval)
+		} else if !lease.renewing {
+			lease.markLost()
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		if callbacks.OnElected != nil {
+			go callbacks.OnElected(leaderCtx)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-lease.Lost():
+		}
+
+		cancel()
+		if callbacks.OnDemoted != nil {
+			callbacks.OnDemoted()
+		}
+
+		releaseCtx, release := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = locker.Release(releaseCtx, lease)
+		release()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
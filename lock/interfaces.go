@@ -0,0 +1,15 @@
+package lock
+
+import "context"
+
+type Locker interface {
+	Acquire(ctx context.Context, name string) (*Lease, error)
+	TryAcquire(ctx context.Context, name string) (*Lease, error)
+	Renew(ctx context.Context, lease *Lease) error
+	Release(ctx context.Context, lease *Lease) error
+}
+
+type Callbacks struct {
+	OnElected func(ctx context.Context)
+	OnDemoted func()
+}
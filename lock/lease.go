@@ -0,0 +1,85 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type Lease struct {
+	Name  string
+	Owner string
+	Token int64
+
+	mutex    *sync.Mutex
+	expires  time.Time
+	renewing bool
+	once     *sync.Once
+	lostOnce *sync.Once
+	stop     chan struct{}
+	lost     chan struct{}
+}
+
+func newLease(name string, owner string, token int64, expires time.Time) *Lease {
+	return &Lease{
+		Name:     name,
+		Owner:    owner,
+		Token:    token,
+		mutex:    &sync.Mutex{},
+		expires:  expires,
+		once:     &sync.Once{},
+		lostOnce: &sync.Once{},
+		stop:     make(chan struct{}),
+		lost:     make(chan struct{}),
+	}
+}
+
+// Expires is safe to call while the lease is being renewed.
+func (l *Lease) Expires() time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.expires
+}
+
+func (l *Lease) setExpires(expires time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.expires = expires
+}
+
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lease) halt() {
+	l.once.Do(func() { close(l.stop) })
+}
+
+func (l *Lease) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
+
+func keepAlive(locker Locker, lease *Lease, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lease.stop:
+			return
+
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := locker.Renew(ctx, lease)
+			cancel()
+
+			if errors.Is(err, ErrLockLost) || (err != nil && time.Now().After(lease.Expires())) {
+				lease.markLost()
+				return
+			}
+		}
+	}
+}
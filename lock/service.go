@@ -0,0 +1,162 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/mongo"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrLocked   = errors.New("lock is held by another owner")
+	ErrLockLost = errors.New("lock is no longer held")
+	// ErrInvalidConfig is returned when TTL is too short to renew at a third of it
+	// or RetryInterval is not positive.
+	ErrInvalidConfig = errors.New("lock ttl and retry interval must be positive")
+)
+
+type service struct {
+	collection *driver.Collection
+	config     Config
+}
+
+func New(ctx context.Context, repo mongo.Repository, cfg ...Config) (Locker, error) {
+	config := NewConfig()
+	if len(cfg) != 0 {
+		config = cfg[0]
+	}
+
+	if config.TTL/3 <= 0 || config.RetryInterval <= 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	collection, err := repo.Collection(ctx, config.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	index := driver.IndexModel{
+		Keys:    bson.D{{Key: "expiresat", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if _, err = collection.Indexes().CreateOne(ctx, index); err != nil {
+		return nil, err
+	}
+
+	return &service{collection: collection, config: config}, nil
+}
+
+func (s *service) Acquire(ctx context.Context, name string) (*Lease, error) {
+	for {
+		lease, err := s.TryAcquire(ctx, name)
+		if !errors.Is(err, ErrLocked) {
+			return lease, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.config.RetryInterval):
+		}
+	}
+}
+
+func (s *service) TryAcquire(ctx context.Context, name string) (*Lease, error) {
+	token, err := s.nextToken(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(s.config.TTL)
+
+	// Each lease gets its own owner ID so that goroutines sharing a Locker
+	// do not re-enter each other's locks.
+	owner := s.config.Owner + "/" + uuid.NewString()
+
+	filter := bson.D{
+		{Key: "_id", Value: name},
+		{Key: "expiresat", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "owner", Value: owner},
+		{Key: "token", Value: token},
+		{Key: "expiresat", Value: expires},
+	}}}
+
+	_, err = s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if driver.IsDuplicateKeyError(err) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+
+	lease := newLease(name, owner, token, expires)
+	if s.config.AutoRenew {
+		lease.renewing = true
+		go keepAlive(s, lease, s.config.TTL/3)
+	}
+
+	return lease, nil
+}
+
+func (s *service) Renew(ctx context.Context, lease *Lease) error {
+	expires := time.Now().UTC().Add(s.config.TTL)
+
+	filter := bson.D{{Key: "_id", Value: lease.Name}, {Key: "owner", Value: lease.Owner}, {Key: "token", Value: lease.Token}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "expiresat", Value: expires}}}}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+
+	lease.setExpires(expires)
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, lease *Lease) error {
+	lease.halt()
+
+	filter := bson.D{{Key: "_id", Value: lease.Name}, {Key: "owner", Value: lease.Owner}, {Key: "token", Value: lease.Token}}
+
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (s *service) nextToken(ctx context.Context, name string) (int64, error) {
+	filter := bson.D{{Key: "_id", Value: name + ":fencing"}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "token", Value: int64(1)}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Token int64
+	}
+
+	if err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter); err != nil {
+		return 0, err
+	}
+
+	return counter.Token, nil
+}
@@ -0,0 +1,209 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deletedKey is set by entity.Entity.SetDeleted; the helpers leave those documents out.
+const deletedKey = "deleted"
+
+type GroupCount struct {
+	Value interface{} `bson:"_id"`
+	Count int64
+}
+
+func (repo *repository) Distinct(ctx context.Context, object StorableObject, field string, filters ...Filter) ([]interface{}, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	filter, err := repo.scopedFilter(ctx, object, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return nil, err
+	}
+
+	return coll.Distinct(ctx, field, filter)
+}
+
+func (repo *repository) Exists(ctx context.Context, object StorableObject, filters ...Filter) (bool, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer repo.end()
+
+	filter, err := repo.scopedFilter(ctx, object, filters)
+	if err != nil {
+		return false, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return false, err
+	}
+
+	count, err := coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (repo *repository) CountBy(ctx context.Context, object StorableObject, field string, filters ...Filter) ([]GroupCount, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	group := bson.D{{Key: "_id", Value: "$" + field}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}
+	sort := bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}
+
+	groups := make([]GroupCount, 0)
+	if err := repo.group(ctx, object, filters, group, sort, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (repo *repository) Sum(ctx context.Context, object StorableObject, field string, filters ...Filter) (float64, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	return repo.accumulate(ctx, object, "$sum", field, filters)
+}
+
+func (repo *repository) Avg(ctx context.Context, object StorableObject, field string, filters ...Filter) (float64, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	return repo.accumulate(ctx, object, "$avg", field, filters)
+}
+
+func (repo *repository) accumulate(ctx context.Context, object StorableObject, operator string, field string, filters []Filter) (float64, error) {
+	group := bson.D{{Key: "_id", Value: nil}, {Key: "value", Value: bson.D{{Key: operator, Value: "$" + field}}}}
+
+	var results []struct {
+		Value float64
+	}
+
+	if err := repo.group(ctx, object, filters, group, nil, &results); err != nil {
+		return 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[0].Value, nil
+}
+
+func (repo *repository) group(ctx context.Context, object StorableObject, filters []Filter, group bson.D, sort bson.D, out interface{}) error {
+	filter, err := repo.scopedFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}, {{Key: "$group", Value: group}}}
+	if sort != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, out)
+}
+
+func (repo *repository) scopedFilter(ctx context.Context, object StorableObject, filters []Filter) (interface{}, error) {
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, err := repo.conditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withConditions(filter, append(conditions, bson.E{Key: deletedKey, Value: nil})), nil
+}
+
+func Distinct[T any](ctx context.Context, repo Repository, object StorableObject, field string, filters ...Filter) ([]T, error) {
+	values, err := repo.Distinct(ctx, object, field, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]T, 0, len(values))
+	for _, value := range values {
+		var v T
+		if err = convert(repo, value, &v); err != nil {
+			return nil, err
+		}
+
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func CountBy[K comparable](ctx context.Context, repo Repository, object StorableObject, field string, filters ...Filter) (map[K]int64, error) {
+	groups, err := repo.CountBy(ctx, object, field, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[K]int64, len(groups))
+	for _, group := range groups {
+		var key K
+		if err = convert(repo, group.Value, &key); err != nil {
+			return nil, err
+		}
+
+		out[key] = group.Count
+	}
+
+	return out, nil
+}
+
+// convert decodes with the repository registry so binary UUID IDs come back as strings.
+func convert(repo Repository, value interface{}, out interface{}) error {
+	t, data, err := bson.MarshalValue(value)
+	if err != nil {
+		return err
+	}
+
+	registry := bson.DefaultRegistry
+	if r, ok := repo.(*repository); ok {
+		registry = r.registry
+	}
+
+	return bson.UnmarshalValueWithRegistry(registry, t, data, out)
+}
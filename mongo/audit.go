@@ -0,0 +1,65 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pedrobarbosak/go-utils/entity"
+)
+
+var ErrNoActor = errors.New("no user in context")
+
+type auditable interface {
+	SetCreated(userID string)
+	SetUpdated(userID string)
+}
+
+func (repo *repository) actor(ctx context.Context) (string, error) {
+	if userID, ok := entity.UserFromContext(ctx); ok {
+		return userID, nil
+	}
+
+	if repo.config.SystemActor != "" {
+		return repo.config.SystemActor, nil
+	}
+
+	return "", ErrNoActor
+}
+
+func (repo *repository) stampCreated(ctx context.Context, object interface{}) error {
+	if !repo.config.AutoAudit {
+		return nil
+	}
+
+	obj, ok := object.(auditable)
+	if !ok {
+		return nil
+	}
+
+	userID, err := repo.actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	obj.SetCreated(userID)
+	return nil
+}
+
+func (repo *repository) stampUpdated(ctx context.Context, object interface{}) error {
+	if !repo.config.AutoAudit {
+		return nil
+	}
+
+	obj, ok := object.(auditable)
+	if !ok {
+		return nil
+	}
+
+	userID, err := repo.actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	obj.SetUpdated(userID)
+	return nil
+}
@@ -0,0 +1,217 @@
+package mongo
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+	DeletePrefix(prefix string)
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+type lruCache struct {
+	mutex   *sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func NewLRUCache(size int, ttl time.Duration) Cache {
+	return &lruCache{
+		mutex:   &sync.Mutex{},
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *lruCache) Set(key string, value []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *lruCache) DeletePrefix(prefix string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *lruCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
+
+// cachePrefix quotes the tenant and collection so IDs containing "/" cannot
+// collide with another tenant's keys.
+func (repo *repository) cachePrefix(ctx context.Context, collection string) string {
+	tenantID, _ := TenantFromContext(ctx)
+	return strconv.Quote(tenantID) + strconv.Quote(collection) + "/"
+}
+
+func (repo *repository) findByID(ctx context.Context, collection string, objectID string) (bson.Raw, error) {
+	useCache := repo.config.Cache != nil && mongo.SessionFromContext(ctx) == nil
+	key := repo.cachePrefix(ctx, collection) + objectID
+
+	generation := repo.generation.Load()
+	if useCache {
+		if raw, ok := repo.config.Cache.Get(key); ok {
+			return raw, nil
+		}
+	}
+
+	filter, err := repo.filterByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	coll, err := repo.collection(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := coll.FindOne(ctx, filter).DecodeBytes()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNoResults
+		}
+		return nil, err
+	}
+
+	// An invalidation that ran while the document was being read would be
+	// undone by this Set, so the possibly stale entry is dropped again.
+	if useCache {
+		repo.config.Cache.Set(key, raw)
+		if repo.generation.Load() != generation {
+			repo.config.Cache.Delete(key)
+		}
+	}
+
+	return raw, nil
+}
+
+type pendingKey struct{}
+
+// pending collects invalidations issued inside a transaction so they only run once it has committed.
+type pending struct {
+	mutex *sync.Mutex
+	funcs []func()
+}
+
+func (p *pending) add(fn func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.funcs = append(p.funcs, fn)
+}
+
+func (p *pending) flush() {
+	p.mutex.Lock()
+	funcs := p.funcs
+	p.funcs = nil
+	p.mutex.Unlock()
+
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+func withPending(ctx context.Context) (context.Context, *pending) {
+	p := &pending{mutex: &sync.Mutex{}}
+	return context.WithValue(ctx, pendingKey{}, p), p
+}
+
+func (repo *repository) afterCommit(ctx context.Context, fn func()) {
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok && inTransaction(ctx) {
+		p.add(fn)
+		return
+	}
+
+	fn()
+}
+
+func (repo *repository) invalidate(ctx context.Context, collection string, objectID string) {
+	if repo.config.Cache != nil {
+		key := repo.cachePrefix(ctx, collection) + objectID
+		repo.afterCommit(ctx, func() {
+			repo.generation.Add(1)
+			repo.config.Cache.Delete(key)
+		})
+	}
+}
+
+func (repo *repository) invalidateCollection(ctx context.Context, collection string) {
+	if repo.config.Cache != nil {
+		prefix := repo.cachePrefix(ctx, collection)
+		repo.afterCommit(ctx, func() {
+			repo.generation.Add(1)
+			repo.config.Cache.DeletePrefix(prefix)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CausalHeader = "X-Causal-Token"
+
+var (
+	ErrNoSession          = errors.New("no session in context")
+	ErrInvalidCausalToken = errors.New("invalid causal token")
+)
+
+type causalToken struct {
+	ClusterTime   bson.Raw            `bson:"c,omitempty"`
+	OperationTime primitive.Timestamp `bson:"o,omitempty"`
+}
+
+func (repo *repository) CausalSession(ctx context.Context, token string) (context.Context, func(), error) {
+	session, err := repo.client.StartSession(options.Session().SetCausalConsistency(true))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if token != "" {
+		if err = resumeCausalChain(session, token); err != nil {
+			session.EndSession(ctx)
+			return nil, nil, err
+		}
+	}
+
+	end := func() { session.EndSession(context.Background()) }
+	return mongo.NewSessionContext(ctx, session), end, nil
+}
+
+func (repo *repository) CausalToken(ctx context.Context) (string, error) {
+	session := mongo.SessionFromContext(ctx)
+	if session == nil {
+		return "", ErrNoSession
+	}
+
+	token := causalToken{ClusterTime: session.ClusterTime()}
+	if operationTime := session.OperationTime(); operationTime != nil {
+		token.OperationTime = *operationTime
+	}
+
+	b, err := bson.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func resumeCausalChain(session mongo.Session, token string) error {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCausalToken
+	}
+
+	var decoded causalToken
+	if err = bson.Unmarshal(b, &decoded); err != nil {
+		return ErrInvalidCausalToken
+	}
+
+	if decoded.ClusterTime != nil {
+		if err = session.AdvanceClusterTime(decoded.ClusterTime); err != nil {
+			return err
+		}
+	}
+
+	if !decoded.OperationTime.IsZero() {
+		if err = session.AdvanceOperationTime(&decoded.OperationTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func inTransaction(ctx context.Context) bool {
+	session, ok := mongo.SessionFromContext(ctx).(mongo.XSession)
+	return ok && session.ClientSession().TransactionRunning()
+}
@@ -0,0 +1,259 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TimeSeries struct {
+	TimeField   string
+	MetaField   string
+	Granularity string
+	ExpireAfter time.Duration
+}
+
+type Capped struct {
+	Size int64
+	Max  int64
+}
+
+type TTL struct {
+	Field       string
+	ExpireAfter time.Duration
+}
+
+type TimeSeriesObject interface {
+	StorableObject
+	TimeSeries() TimeSeries
+}
+
+type CappedObject interface {
+	StorableObject
+	Capped() Capped
+}
+
+type TTLObject interface {
+	StorableObject
+	TTL() TTL
+}
+
+type CollectionMismatch struct {
+	Collection string
+	Problem    string
+}
+
+func (m *CollectionMismatch) Error() string {
+	return fmt.Sprintf("collection %s: %s", m.Collection, m.Problem)
+}
+
+type collectionOptions struct {
+	Capped     bool  `bson:"capped"`
+	Size       int64 `bson:"size"`
+	Max        int64 `bson:"max"`
+	TimeSeries *struct {
+		TimeField   string `bson:"timeField"`
+		MetaField   string `bson:"metaField"`
+		Granularity string `bson:"granularity"`
+	} `bson:"timeseries"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+func (repo *repository) EnsureCollections(ctx context.Context, objects ...StorableObject) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	db, err := repo.db(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, object := range objects {
+		if err = repo.ensureCollection(ctx, db, object); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func (repo *repository) ensureCollection(ctx context.Context, db *mongo.Database, object StorableObject) error {
+	name := object.GetCollection()
+
+	specs, err := db.ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return err
+	}
+
+	if len(specs) == 0 {
+		if err = db.CreateCollection(ctx, name, createOptions(object)); err != nil {
+			return err
+		}
+	} else if err = verifyCollection(object, specs[0]); err != nil {
+		return err
+	}
+
+	if declared, ok := object.(TTLObject); ok {
+		return repo.ensureTTLIndex(ctx, db.Collection(name), declared.TTL())
+	}
+
+	return nil
+}
+
+func createOptions(object StorableObject) *options.CreateCollectionOptions {
+	opts := options.CreateCollection()
+
+	if declared, ok := object.(TimeSeriesObject); ok {
+		ts := declared.TimeSeries()
+
+		tsOpts := options.TimeSeries().SetTimeField(ts.TimeField)
+		if ts.MetaField != "" {
+			tsOpts.SetMetaField(ts.MetaField)
+		}
+
+		if ts.Granularity != "" {
+			tsOpts.SetGranularity(ts.Granularity)
+		}
+
+		opts.SetTimeSeriesOptions(tsOpts)
+
+		if ts.ExpireAfter > 0 {
+			opts.SetExpireAfterSeconds(int64(ts.ExpireAfter.Seconds()))
+		}
+	}
+
+	if declared, ok := object.(CappedObject); ok {
+		capped := declared.Capped()
+
+		opts.SetCapped(true).SetSizeInBytes(capped.Size)
+		if capped.Max > 0 {
+			opts.SetMaxDocuments(capped.Max)
+		}
+	}
+
+	return opts
+}
+
+func verifyCollection(object StorableObject, spec *mongo.CollectionSpecification) error {
+	var existing collectionOptions
+	if spec.Options != nil {
+		if err := bson.Unmarshal(spec.Options, &existing); err != nil {
+			return err
+		}
+	}
+
+	var errs error
+	mismatch := func(format string, args ...any) {
+		errs = errors.Join(errs, &CollectionMismatch{Collection: spec.Name, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	if declared, ok := object.(TimeSeriesObject); ok {
+		ts := declared.TimeSeries()
+
+		switch {
+		case existing.TimeSeries == nil:
+			mismatch("expected time-series collection, found %s", spec.Type)
+
+		default:
+			if existing.TimeSeries.TimeField != ts.TimeField {
+				mismatch("timeField is %q, expected %q", existing.TimeSeries.TimeField, ts.TimeField)
+			}
+
+			if existing.TimeSeries.MetaField != ts.MetaField {
+				mismatch("metaField is %q, expected %q", existing.TimeSeries.MetaField, ts.MetaField)
+			}
+
+			if ts.Granularity != "" && existing.TimeSeries.Granularity != ts.Granularity {
+				mismatch("granularity is %q, expected %q", existing.TimeSeries.Granularity, ts.Granularity)
+			}
+		}
+
+		expected := int64(ts.ExpireAfter.Seconds())
+		if actual := valueOrZero(existing.ExpireAfterSeconds); actual != expected {
+			mismatch("expireAfterSeconds is %d, expected %d", actual, expected)
+		}
+	} else if existing.TimeSeries != nil {
+		mismatch("unexpected time-series collection")
+	}
+
+	if declared, ok := object.(CappedObject); ok {
+		capped := declared.Capped()
+
+		switch {
+		case !existing.Capped:
+			mismatch("expected capped collection")
+
+		default:
+			if existing.Size < capped.Size || existing.Size >= capped.Size+256 {
+				mismatch("size is %d, expected %d", existing.Size, capped.Size)
+			}
+
+			if capped.Max > 0 && existing.Max != capped.Max {
+				mismatch("max is %d, expected %d", existing.Max, capped.Max)
+			}
+		}
+	} else if existing.Capped {
+		mismatch("unexpected capped collection")
+	}
+
+	return errs
+}
+
+func (repo *repository) ensureTTLIndex(ctx context.Context, coll *mongo.Collection, ttl TTL) error {
+	expected := int64(ttl.ExpireAfter.Seconds())
+
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var indexes []struct {
+		Key                bson.D `bson:"key"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	}
+
+	if err = cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		if len(index.Key) != 1 || index.Key[0].Key != ttl.Field {
+			continue
+		}
+
+		if index.ExpireAfterSeconds == nil {
+			return &CollectionMismatch{Collection: coll.Name(), Problem: fmt.Sprintf("index on %s has no expireAfterSeconds", ttl.Field)}
+		}
+
+		if *index.ExpireAfterSeconds != expected {
+			return &CollectionMismatch{Collection: coll.Name(), Problem: fmt.Sprintf("expireAfterSeconds on %s is %d, expected %d", ttl.Field, *index.ExpireAfterSeconds, expected)}
+		}
+
+		return nil
+	}
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: ttl.Field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(expected)),
+	}
+
+	_, err = coll.Indexes().CreateOne(ctx, index)
+	return err
+}
+
+func valueOrZero(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
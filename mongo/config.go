@@ -13,6 +13,15 @@ type config struct {
 	IDType              IDType
 	AutoPreload         bool
 	ClearEmbeddedFields bool
+	AutoAudit           bool
+	SystemActor         string
+	History             bool
+	Tenancy             Tenancy
+	TenantKey           string
+	KeyProvider         KeyProvider
+	Cache               Cache
+	FilesBucket         string
+	SearchScoreField    string
 	Driver              *driver
 }
 
@@ -26,6 +35,9 @@ func newDefaultConfig() *config {
 		IDType:              ObjectID,
 		AutoPreload:         true,
 		ClearEmbeddedFields: true,
+		TenantKey:           "tenantid",
+		FilesBucket:         "fs",
+		SearchScoreField:    "score",
 	}
 }
 
@@ -86,6 +98,49 @@ func (c *config) SetClearEmbeddedFields(value bool) {
 	c.ClearEmbeddedFields = value
 }
 
+func (c *config) SetAutoAudit(value bool) {
+	c.AutoAudit = value
+}
+
+func (c *config) SetSystemActor(userID string) {
+	c.SystemActor = userID
+}
+
+func (c *config) SetHistory(value bool) {
+	c.History = value
+}
+
+func (c *config) SetTenancy(t Tenancy) {
+	if t.isValid() {
+		c.Tenancy = t
+	}
+}
+
+func (c *config) SetTenantKey(key string) {
+	if key != "" {
+		c.TenantKey = key
+	}
+}
+
+func (c *config) SetKeyProvider(provider KeyProvider) {
+	c.KeyProvider = provider
+}
+
+func (c *config) SetCache(cache Cache) {
+	c.Cache = cache
+}
+
+func (c *config) SetFilesBucket(name string) {
+	if name != "" {
+		c.FilesBucket = name
+	}
+}
+
+// SetSearchScoreField sets the field Search projects the text score into; empty disables the projection.
+func (c *config) SetSearchScoreField(field string) {
+	c.SearchScoreField = field
+}
+
 func (c *config) SetDriver(client *mongo.Client, database *mongo.Database) {
 	if client != nil && database != nil {
 		c.Driver = &driver{Client: client, Database: database}
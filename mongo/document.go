@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (repo *repository) document(ctx context.Context, object interface{}) (interface{}, error) {
+	tenant := repo.config.Tenancy == TenantField
+	binaryIDs := repo.config.IDType == UUID
+
+	if !tenant && !binaryIDs {
+		return object, nil
+	}
+
+	b, err := bson.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err = bson.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	if binaryIDs {
+		encodeUUIDs(doc)
+	}
+
+	if !tenant {
+		return doc, nil
+	}
+
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := make(bson.D, 0, len(doc)+1)
+	for _, e := range doc {
+		if e.Key != repo.config.TenantKey {
+			scoped = append(scoped, e)
+		}
+	}
+
+	return append(scoped, bson.E{Key: repo.config.TenantKey, Value: tenantID}), nil
+}
+
+func toDocument(value interface{}) (bson.D, error) {
+	b, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err = bson.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,394 @@
+package mongo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	encryptTag    = "m-encrypt"
+	deterministic = "deterministic"
+	cipherPrefix  = "enc:v1:"
+)
+
+var (
+	ErrNoKeyProvider     = errors.New("key provider is required for encrypted fields")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrEncryptNotPointer = errors.New("objects with m-encrypt fields must be passed by pointer")
+)
+
+type KeyProvider interface {
+	CurrentKey(ctx context.Context) (keyID string, key []byte, err error)
+	Key(ctx context.Context, keyID string) ([]byte, error)
+	// KeyIDs lists every key data may still be encrypted with, so deterministic
+	// filters keep matching values written before a rotation.
+	KeyIDs(ctx context.Context) ([]string, error)
+}
+
+type staticKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) KeyProvider {
+	return &staticKeys{current: currentKeyID, keys: keys}
+}
+
+func (p *staticKeys) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := p.Key(ctx, p.current)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return p.current, key, nil
+}
+
+func (p *staticKeys) KeyIDs(_ context.Context) ([]string, error) {
+	keyIDs := []string{p.current}
+	for keyID := range p.keys {
+		if keyID != p.current {
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+
+	return keyIDs, nil
+}
+
+func (p *staticKeys) Key(_ context.Context, keyID string) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, errors.New("unknown key: " + keyID)
+	}
+
+	return key, nil
+}
+
+type encryptedField struct {
+	value         reflect.Value
+	name          string
+	deterministic bool
+}
+
+func encryptedFields(object interface{}) ([]encryptedField, error) {
+	values := reflect.ValueOf(object)
+	if values.Kind() == reflect.Ptr {
+		values = values.Elem()
+	}
+
+	if values.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	fields := make([]encryptedField, 0)
+	if err := collectEncryptedFields(values, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func collectEncryptedFields(values reflect.Value, out *[]encryptedField) error {
+	types := values.Type()
+
+	num := values.NumField()
+	for i := 0; i < num; i++ {
+		field := types.Field(i)
+		value := values.Field(i)
+
+		mode, exists := field.Tag.Lookup(encryptTag)
+		if !exists {
+			if field.Anonymous && value.Kind() == reflect.Struct {
+				if err := collectEncryptedFields(value, out); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if value.Kind() != reflect.String {
+			return errors.New("m-encrypt supports string fields only: " + field.Name)
+		}
+
+		*out = append(*out, encryptedField{value: value, name: bsonName(field), deterministic: mode == deterministic})
+	}
+
+	return nil
+}
+
+func bsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+
+	return name
+}
+
+func (repo *repository) encrypt(ctx context.Context, object interface{}) (func(), error) {
+	fields, err := encryptedFields(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) != 0 && !fields[0].value.CanSet() {
+		return nil, ErrEncryptNotPointer
+	}
+
+	plaintexts := make([]string, len(fields))
+	restore := func() {
+		for i, field := range fields {
+			field.value.SetString(plaintexts[i])
+		}
+	}
+
+	for i, field := range fields {
+		plaintexts[i] = field.value.String()
+
+		ciphertext, err := repo.encryptValue(ctx, plaintexts[i], field.deterministic)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+
+		field.value.SetString(ciphertext)
+	}
+
+	return restore, nil
+}
+
+func (repo *repository) decrypt(ctx context.Context, object interface{}) error {
+	fields, err := encryptedFields(object)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) != 0 && !fields[0].value.CanSet() {
+		return ErrEncryptNotPointer
+	}
+
+	for _, field := range fields {
+		plaintext, err := repo.decryptValue(ctx, field.value.String())
+		if err != nil {
+			return err
+		}
+
+		field.value.SetString(plaintext)
+	}
+
+	return nil
+}
+
+func (repo *repository) encryptFilters(ctx context.Context, object interface{}, filters []Filter) ([]Filter, error) {
+	fields, err := encryptedFields(object)
+	if err != nil || len(fields) == 0 {
+		return filters, err
+	}
+
+	out := make([]Filter, 0, len(filters))
+	for _, filter := range filters {
+		for _, field := range fields {
+			value, ok := filter.Value.(string)
+			if !ok || !field.deterministic || field.name != filter.Key {
+				continue
+			}
+
+			ciphertexts, err := repo.encryptAllKeys(ctx, value)
+			if err != nil {
+				return nil, err
+			}
+
+			filter.Value = ciphertexts[0]
+			if len(ciphertexts) > 1 {
+				filter.Value = bson.M{"$in": ciphertexts}
+			}
+			break
+		}
+
+		out = append(out, filter)
+	}
+
+	return out, nil
+}
+
+func (repo *repository) encryptPatch(ctx context.Context, object interface{}, fields interface{}) (interface{}, error) {
+	encrypted, err := encryptedFields(object)
+	if err != nil || len(encrypted) == 0 {
+		return fields, err
+	}
+
+	doc, err := toDocument(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range doc {
+		for _, field := range encrypted {
+			if field.name != e.Key || e.Value == nil {
+				continue
+			}
+
+			value, ok := e.Value.(string)
+			if !ok {
+				return nil, errors.New("m-encrypt supports string fields only: " + e.Key)
+			}
+
+			if doc[i].Value, err = repo.encryptValue(ctx, value, field.deterministic); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return doc, nil
+}
+
+func (repo *repository) encryptValue(ctx context.Context, plaintext string, deterministic bool) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	if repo.config.KeyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+
+	keyID, key, err := repo.config.KeyProvider.CurrentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptWith(keyID, key, plaintext, deterministic)
+}
+
+// encryptAllKeys deterministically encrypts plaintext under every active key.
+func (repo *repository) encryptAllKeys(ctx context.Context, plaintext string) ([]string, error) {
+	if plaintext == "" {
+		return []string{""}, nil
+	}
+
+	if repo.config.KeyProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	keyIDs, err := repo.config.KeyProvider.KeyIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyIDs) == 0 {
+		ciphertext, err := repo.encryptValue(ctx, plaintext, true)
+		return []string{ciphertext}, err
+	}
+
+	ciphertexts := make([]string, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		key, err := repo.config.KeyProvider.Key(ctx, keyID)
+		if err != nil {
+			return nil, err
+		}
+
+		ciphertext, err := encryptWith(keyID, key, plaintext, true)
+		if err != nil {
+			return nil, err
+		}
+
+		ciphertexts = append(ciphertexts, ciphertext)
+	}
+
+	return ciphertexts, nil
+}
+
+func encryptWith(keyID string, key []byte, plaintext string, deterministic bool) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		nonceKey, err := deriveKey(key, "m-encrypt nonce")
+		if err != nil {
+			return "", err
+		}
+
+		mac := hmac.New(sha256.New, nonceKey)
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(keyID))
+	return cipherPrefix + keyID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (repo *repository) decryptValue(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, cipherPrefix) {
+		return value, nil
+	}
+
+	if repo.config.KeyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, cipherPrefix), ":")
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	key, err := repo.config.KeyProvider.Key(ctx, keyID)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+// deriveKey keeps the AES key out of other primitives.
+func deriveKey(key []byte, info string) ([]byte, error) {
+	derived := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(info)), derived); err != nil {
+		return nil, err
+	}
+
+	return derived, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
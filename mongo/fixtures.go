@@ -0,0 +1,386 @@
+package mongo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v3"
+)
+
+type Format uint
+
+const (
+	ExtJSON Format = iota
+	JSONLines
+	YAML
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ExtJSON, nil
+	case ".jsonl", ".ndjson":
+		return JSONLines, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+}
+
+func (repo *repository) LoadFixture(ctx context.Context, object StorableObject, path string) (int, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.Close()
+
+	return repo.Import(ctx, object, f, format)
+}
+
+func (repo *repository) Import(ctx context.Context, object StorableObject, r io.Reader, format Format) (int, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	count := 0
+	var maxID int64
+
+	create := func(data []byte) error {
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+			return err
+		}
+
+		b, err := bson.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		obj := reflect.New(reflect.TypeOf(object).Elem()).Interface().(StorableObject)
+		if err = repo.unmarshal(b, obj); err != nil {
+			return err
+		}
+
+		// Exported documents hold ciphertext; decrypt so Create encrypts once.
+		if err = repo.decrypt(ctx, obj); err != nil {
+			return err
+		}
+
+		// Unlike Create, the exported audit fields are kept as they are.
+		if err = repo.create(ctx, obj, repo.importDocument); err != nil {
+			return err
+		}
+
+		if repo.config.IDType == Sequence {
+			if id, err := strconv.ParseInt(obj.GetID(), 10, 64); err == nil && id > maxID {
+				maxID = id
+			}
+		}
+
+		count++
+		return nil
+	}
+
+	switch format {
+	case ExtJSON:
+		err = readExtJSON(r, create)
+	case JSONLines:
+		err = readJSONLines(r, create)
+	case YAML:
+		err = readYAML(r, create)
+	default:
+		err = ErrUnsupportedFormat
+	}
+
+	if maxID > 0 {
+		if seqErr := repo.advanceSequence(ctx, object.GetCollection(), maxID); err == nil {
+			err = seqErr
+		}
+	}
+
+	return count, err
+}
+
+// importDocument stores exported ObjectIDs, which decode into hex strings,
+// back as ObjectIDs instead of strings.
+func (repo *repository) importDocument(ctx context.Context, object interface{}) (interface{}, error) {
+	doc, err := repo.document(ctx, object)
+	if err != nil || repo.config.IDType != ObjectID {
+		return doc, err
+	}
+
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var d bson.D
+	if err = bson.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+
+	for i, e := range d {
+		if id, ok := e.Value.(string); ok && e.Key == "_id" {
+			if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+				d[i].Value = objectID
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func (repo *repository) Export(ctx context.Context, object StorableObject, w io.Writer, format Format, filters ...Filter) (int, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := coll.Find(ctx, scoped)
+	if err != nil {
+		return 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	var writer recordWriter
+	switch format {
+	case ExtJSON:
+		writer = &extJSONWriter{w: w}
+	case JSONLines:
+		writer = &jsonLinesWriter{w: w}
+	case YAML:
+		writer = &yamlWriter{encoder: yaml.NewEncoder(w)}
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+
+	count := 0
+	for cursor.Next(ctx) {
+		if err = writer.write(cursor.Current); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err = cursor.Err(); err != nil {
+		return count, err
+	}
+
+	return count, writer.close()
+}
+
+func readExtJSON(r io.Reader, fn func(data []byte) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.New("extended json fixtures must be an array of documents")
+	}
+
+	for decoder.More() {
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			return err
+		}
+
+		if err = fn(raw); err != nil {
+			return err
+		}
+	}
+
+	_, err = decoder.Token()
+	return err
+}
+
+func readJSONLines(r io.Reader, fn func(data []byte) error) error {
+	const maxLine = 16 * 1024 * 1024
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if err := fn([]byte(line)); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func readYAML(r io.Reader, fn func(data []byte) error) error {
+	decoder := yaml.NewDecoder(r)
+
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		records, ok := doc.([]interface{})
+		if !ok {
+			records = []interface{}{doc}
+		}
+
+		for _, record := range records {
+			if record == nil {
+				continue
+			}
+
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+
+			if err = fn(data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type recordWriter interface {
+	write(doc bson.Raw) error
+	close() error
+}
+
+type extJSONWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *extJSONWriter) write(doc bson.Raw) error {
+	data, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n"
+	if !e.started {
+		prefix = "[\n"
+		e.started = true
+	}
+
+	if _, err = io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *extJSONWriter) close() error {
+	if !e.started {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+type jsonLinesWriter struct {
+	w io.Writer
+}
+
+func (j *jsonLinesWriter) write(doc bson.Raw) error {
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(append(data, '\n'))
+	return err
+}
+
+func (j *jsonLinesWriter) close() error {
+	return nil
+}
+
+type yamlWriter struct {
+	encoder *yaml.Encoder
+}
+
+func (y *yamlWriter) write(doc bson.Raw) error {
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err = yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+
+	resetStyle(&node)
+	return y.encoder.Encode(&node)
+}
+
+func (y *yamlWriter) close() error {
+	return y.encoder.Close()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
@@ -0,0 +1,55 @@
+package mongo
+
+const earthRadius = 6378100.0
+
+type Point struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func NewPoint(longitude float64, latitude float64) Point {
+	return Point{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+func (p Point) Longitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+
+	return p.Coordinates[0]
+}
+
+func (p Point) Latitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+
+	return p.Coordinates[1]
+}
+
+type Polygon struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+func NewPolygon(rings ...[]Point) Polygon {
+	coordinates := make([][][]float64, 0, len(rings))
+	for _, ring := range rings {
+		positions := make([][]float64, 0, len(ring)+1)
+		for _, point := range ring {
+			positions = append(positions, []float64{point.Longitude(), point.Latitude()})
+		}
+
+		if len(ring) > 0 && !samePosition(ring[0], ring[len(ring)-1]) {
+			positions = append(positions, []float64{ring[0].Longitude(), ring[0].Latitude()})
+		}
+
+		coordinates = append(coordinates, positions)
+	}
+
+	return Polygon{Type: "Polygon", Coordinates: coordinates}
+}
+
+func samePosition(a Point, b Point) bool {
+	return a.Longitude() == b.Longitude() && a.Latitude() == b.Latitude()
+}
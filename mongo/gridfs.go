@@ -0,0 +1,350 @@
+package mongo
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var fileType = reflect.TypeOf(File{})
+
+type File struct {
+	ID         string
+	Name       string
+	Length     int64
+	ChunkSize  int32
+	UploadDate time.Time
+	Metadata   bson.M
+}
+
+type fileDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Name       string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	ChunkSize  int32              `bson:"chunkSize"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   bson.M             `bson:"metadata"`
+}
+
+func (doc *fileDocument) toFile() *File {
+	return &File{
+		ID:         doc.ID.Hex(),
+		Name:       doc.Name,
+		Length:     doc.Length,
+		ChunkSize:  doc.ChunkSize,
+		UploadDate: doc.UploadDate,
+		Metadata:   doc.Metadata,
+	}
+}
+
+func (f File) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if f.ID == "" {
+		return bson.MarshalValue(nil)
+	}
+
+	id, err := primitive.ObjectIDFromHex(f.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return bson.MarshalValue(id)
+}
+
+func (f *File) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t != bson.TypeObjectID {
+		*f = File{}
+		return nil
+	}
+
+	var id primitive.ObjectID
+	if err := bson.UnmarshalValue(t, data, &id); err != nil {
+		return err
+	}
+
+	*f = File{ID: id.Hex()}
+	return nil
+}
+
+func (repo *repository) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	db, err := repo.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(repo.config.FilesBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = bucket.SetReadDeadline(deadline)
+		_ = bucket.SetWriteDeadline(deadline)
+	}
+
+	return bucket, nil
+}
+
+func (repo *repository) filesCollection(ctx context.Context) (*mongo.Collection, error) {
+	return repo.collection(ctx, repo.config.FilesBucket+".files")
+}
+
+func (repo *repository) UploadFile(ctx context.Context, name string, source io.Reader, metadata interface{}) (*File, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	meta := bson.D{}
+	if metadata != nil {
+		b, err := bson.Marshal(metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = bson.Unmarshal(b, &meta); err != nil {
+			return nil, err
+		}
+	}
+
+	if repo.config.Tenancy == TenantField {
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		meta = append(meta, bson.E{Key: repo.config.TenantKey, Value: tenantID})
+	}
+
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := bucket.UploadFromStream(name, source, options.GridFSUpload().SetMetadata(meta))
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetFile(ctx, id.Hex())
+}
+
+func (repo *repository) GetFile(ctx context.Context, fileID string) (*File, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	id, err := primitive.ObjectIDFromHex(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := repo.scopeFiles(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return nil, err
+	}
+
+	coll, err := repo.filesCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc fileDocument
+	if err = coll.FindOne(ctx, filter).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNoResults
+		}
+		return nil, err
+	}
+
+	return doc.toFile(), nil
+}
+
+// fileStream keeps the repository from shutting down until it is closed.
+type fileStream struct {
+	*gridfs.DownloadStream
+	once *sync.Once
+	end  func()
+}
+
+func (s *fileStream) Close() error {
+	err := s.DownloadStream.Close()
+	s.once.Do(s.end)
+	return err
+}
+
+func (repo *repository) OpenFile(ctx context.Context, fileID string) (io.ReadCloser, *File, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream, file, err := repo.openFile(ctx, fileID)
+	if err != nil {
+		repo.end()
+		return nil, nil, err
+	}
+
+	return &fileStream{DownloadStream: stream, once: &sync.Once{}, end: repo.end}, file, nil
+}
+
+func (repo *repository) openFile(ctx context.Context, fileID string) (*gridfs.DownloadStream, *File, error) {
+	file, err := repo.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, _ := primitive.ObjectIDFromHex(file.ID)
+
+	stream, err := bucket.OpenDownloadStream(id)
+	if err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, nil, ErrNoResults
+		}
+		return nil, nil, err
+	}
+
+	return stream, file, nil
+}
+
+func (repo *repository) DownloadFile(ctx context.Context, fileID string, w io.Writer) (int64, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	stream, _, err := repo.OpenFile(ctx, fileID)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stream.Close()
+
+	return io.Copy(w, stream)
+}
+
+func (repo *repository) DeleteFile(ctx context.Context, fileID string) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	file, err := repo.GetFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, _ := primitive.ObjectIDFromHex(file.ID)
+
+	if err = bucket.DeleteContext(ctx, id); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return ErrNoResults
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (repo *repository) ListFiles(ctx context.Context, filters ...Filter) ([]*File, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	filter := bson.M{}
+	for _, property := range filters {
+		filter["metadata."+property.Key] = property.Value
+	}
+
+	scoped, err := repo.scopeFiles(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	coll, err := repo.filesCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := coll.Find(ctx, scoped, options.Find().SetSort(bson.D{{Key: "uploadDate", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*fileDocument, 0)
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	files := make([]*File, 0, len(docs))
+	for _, doc := range docs {
+		files = append(files, doc.toFile())
+	}
+
+	return files, nil
+}
+
+func (repo *repository) scopeFiles(ctx context.Context, filter interface{}) (interface{}, error) {
+	if repo.config.Tenancy != TenantField {
+		return filter, nil
+	}
+
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := bson.D{{Key: "metadata." + repo.config.TenantKey, Value: tenantID}}
+	return bson.D{{Key: "$and", Value: bson.A{filter, condition}}}, nil
+}
+
+func (repo *repository) fetchFile(ctx context.Context, value reflect.Value) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if !value.CanSet() {
+		return nil
+	}
+
+	ref := value.Interface().(File)
+	if ref.ID == "" {
+		return nil
+	}
+
+	file, err := repo.GetFile(ctx, ref.ID)
+	if err != nil {
+		return err
+	}
+
+	value.Set(reflect.ValueOf(*file))
+	return nil
+}
@@ -0,0 +1,237 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+var ErrShuttingDown = errors.New("repository is shutting down")
+
+type Health struct {
+	Latency    time.Duration
+	Topology   string
+	ReplicaSet string
+	Primary    string
+	Me         string
+	Writable   bool
+	HasPrimary bool
+	Hosts      []string
+	Members    []Member
+}
+
+type Member struct {
+	Name   string
+	State  string
+	Health float64
+}
+
+type helloResponse struct {
+	IsWritablePrimary bool     `bson:"isWritablePrimary"`
+	SetName           string   `bson:"setName"`
+	Primary           string   `bson:"primary"`
+	Me                string   `bson:"me"`
+	Hosts             []string `bson:"hosts"`
+	Msg               string   `bson:"msg"`
+}
+
+type replicaSetStatus struct {
+	Members []struct {
+		Name     string  `bson:"name"`
+		StateStr string  `bson:"stateStr"`
+		Health   float64 `bson:"health"`
+	} `bson:"members"`
+}
+
+type inflightKey struct{}
+
+type tracker struct {
+	mutex    *sync.Mutex
+	active   int
+	closed   bool
+	idle     chan struct{}
+	idleOnce *sync.Once
+}
+
+func newTracker() *tracker {
+	return &tracker{mutex: &sync.Mutex{}, idle: make(chan struct{}), idleOnce: &sync.Once{}}
+}
+
+func (t *tracker) begin(nested bool) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed && !nested {
+		return ErrShuttingDown
+	}
+
+	t.active++
+	return nil
+}
+
+func (t *tracker) end() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.active--
+	if t.closed && t.active == 0 {
+		t.idleOnce.Do(func() { close(t.idle) })
+	}
+}
+
+func (t *tracker) close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.closed = true
+	if t.active == 0 {
+		t.idleOnce.Do(func() { close(t.idle) })
+	}
+}
+
+func (t *tracker) wait(ctx context.Context) error {
+	select {
+	case <-t.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin rejects new operations once Shutdown started, but lets operations
+// already in flight, and transactions they run, call back into the repository.
+func (repo *repository) begin(ctx context.Context) (context.Context, error) {
+	nested, _ := ctx.Value(inflightKey{}).(bool)
+	if err := repo.inflight.begin(nested); err != nil {
+		return ctx, err
+	}
+
+	if nested {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, inflightKey{}, true), nil
+}
+
+func (repo *repository) end() {
+	repo.inflight.end()
+}
+
+func (repo *repository) Health(ctx context.Context) (*Health, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	// Any reachable member answers, so a replica set without a primary is
+	// reported through HasPrimary instead of failing the check.
+	start := time.Now()
+	if err := repo.client.Ping(ctx, readpref.Nearest()); err != nil {
+		return nil, err
+	}
+
+	health := &Health{Latency: time.Since(start), Topology: "standalone"}
+
+	admin := repo.client.Database("admin")
+	nearest := options.RunCmd().SetReadPreference(readpref.Nearest())
+
+	var hello helloResponse
+	if err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}, nearest).Decode(&hello); err != nil {
+		return nil, err
+	}
+
+	health.Writable = hello.IsWritablePrimary
+	health.HasPrimary = hello.IsWritablePrimary || hello.Primary != ""
+	health.Primary = hello.Primary
+	health.Me = hello.Me
+	health.Hosts = hello.Hosts
+
+	switch {
+	case hello.Msg == "isdbgrid":
+		health.Topology = "sharded"
+
+	case hello.SetName != "":
+		health.Topology = "replicaset"
+		health.ReplicaSet = hello.SetName
+
+		var status replicaSetStatus
+		if err := admin.RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}, nearest).Decode(&status); err == nil {
+			for _, member := range status.Members {
+				health.Members = append(health.Members, Member{Name: member.Name, State: member.StateStr, Health: member.Health})
+			}
+		}
+	}
+
+	return health, nil
+}
+
+func (repo *repository) Monitor(ctx context.Context, interval time.Duration, onChange func(connected bool, err error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		connected := true
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-repo.closed:
+				return
+			case <-ticker.C:
+			}
+
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := repo.client.Ping(pingCtx, readpref.Primary())
+			cancel()
+
+			if (err == nil) != connected {
+				connected = err == nil
+				onChange(connected, err)
+			}
+		}
+	}()
+}
+
+func (repo *repository) Shutdown(ctx context.Context) error {
+	repo.closeOnce.Do(func() { close(repo.closed) })
+	repo.inflight.close()
+
+	repo.mutex.Lock()
+	background := repo.background
+	repo.background = nil
+	repo.mutex.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		for _, stop := range background {
+			stop()
+		}
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+	}
+
+	err := repo.inflight.wait(ctx)
+
+	if disconnectErr := repo.client.Disconnect(context.WithoutCancel(ctx)); disconnectErr != nil {
+		return disconnectErr
+	}
+
+	return err
+}
+
+func (repo *repository) onShutdown(stop func()) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	repo.background = append(repo.background, stop)
+}
@@ -0,0 +1,209 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const historySuffix = "_history"
+
+type Action string
+
+const (
+	ActionUpdate Action = "update"
+	ActionPatch  Action = "patch"
+	ActionDelete Action = "delete"
+)
+
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+type HistoryEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	EntityID  string
+	Action    Action
+	Changes   []Change
+	Actor     string
+	Timestamp int64
+}
+
+func (repo *repository) History(ctx context.Context, object StorableObject, objectID string) ([]*HistoryEntry, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.end()
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+
+	filter, err := repo.scope(ctx, bson.D{{Key: "entityid", Value: objectID}})
+	if err != nil {
+		return nil, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection()+historySuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*HistoryEntry, 0)
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (repo *repository) inTransaction(ctx context.Context, fn func(sc context.Context) error) error {
+	if inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	return repo.WithTransaction(ctx, fn)
+}
+
+func (repo *repository) recordHistory(ctx context.Context, collection string, objectID string, action Action, before bson.M, after bson.M) error {
+	changes := diff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	actor, _ := repo.actor(ctx)
+
+	entry := &HistoryEntry{
+		EntityID:  objectID,
+		Action:    action,
+		Changes:   changes,
+		Actor:     actor,
+		Timestamp: time.Now().UTC().Unix(),
+	}
+
+	doc, err := repo.document(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, collection+historySuffix)
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.InsertOne(ctx, doc)
+	return err
+}
+
+func (repo *repository) updateWithHistory(ctx context.Context, collection string, objectID string, action Action, filter interface{}, update interface{}) (bson.M, error) {
+	var after bson.M
+
+	err := repo.inTransaction(ctx, func(sc context.Context) error {
+		coll, err := repo.collection(sc, collection)
+		if err != nil {
+			return err
+		}
+
+		var before bson.M
+		if err = coll.FindOneAndUpdate(sc, filter, update).Decode(&before); err != nil {
+			return err
+		}
+
+		// The update may move the document out of filter's scope,
+		// so the after-image is read back by _id only.
+		if err = coll.FindOne(sc, bson.M{"_id": before["_id"]}).Decode(&after); err != nil {
+			return err
+		}
+
+		return repo.recordHistory(sc, collection, objectID, action, before, after)
+	})
+
+	return after, err
+}
+
+func (repo *repository) deleteWithHistory(ctx context.Context, collection string, objectID string, filter interface{}) error {
+	return repo.inTransaction(ctx, func(sc context.Context) error {
+		coll, err := repo.collection(sc, collection)
+		if err != nil {
+			return err
+		}
+
+		var before bson.M
+		if err = coll.FindOneAndDelete(sc, filter).Decode(&before); err != nil {
+			return err
+		}
+
+		return repo.recordHistory(sc, collection, objectID, ActionDelete, before, nil)
+	})
+}
+
+func diff(before bson.M, after bson.M) []Change {
+	old := make(map[string]interface{})
+	flatten("", before, old)
+
+	current := make(map[string]interface{})
+	flatten("", after, current)
+
+	paths := make([]string, 0, len(old)+len(current))
+	for path := range old {
+		paths = append(paths, path)
+	}
+
+	for path := range current {
+		if _, exists := old[path]; !exists {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	changes := make([]Change, 0)
+	for _, path := range paths {
+		o, n := old[path], current[path]
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+
+		changes = append(changes, Change{Path: path, Old: o, New: n})
+	}
+
+	return changes
+}
+
+func flatten(prefix string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case bson.M:
+		for key, val := range v {
+			flatten(join(prefix, key), val, out)
+		}
+
+	case bson.D:
+		for _, e := range v {
+			flatten(join(prefix, e.Key), e.Value, out)
+		}
+
+	default:
+		if prefix != "" {
+			out[prefix] = value
+		}
+	}
+}
+
+func join(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
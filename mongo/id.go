@@ -1,9 +1,18 @@
 package mongo
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type IDType uint
@@ -11,29 +20,167 @@ type IDType uint
 const (
 	String IDType = iota
 	ObjectID
+	UUIDv7
+	ULID
+	Sequence
+	UUID
 )
 
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ErrInvalidID = errors.New("invalid id")
+
 func (id IDType) isValid() bool {
-	return id == String || id == ObjectID
+	return id <= UUID
 }
 
 func (repo *repository) getIDFilter(id string) (bson.D, error) {
-	if repo.config.IDType == String {
-		return bson.D{{Key: "_id", Value: id}}, nil
+	switch repo.config.IDType {
+	case ObjectID:
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		return bson.D{{Key: "_id", Value: objectID}}, nil
+
+	case UUIDv7:
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, ErrInvalidID
+		}
+
+	case ULID:
+		if !isULID(id) {
+			return nil, ErrInvalidID
+		}
+
+	case Sequence:
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return nil, ErrInvalidID
+		}
+
+	case UUID:
+		binary, err := BinaryUUID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		return bson.D{{Key: "_id", Value: binary}}, nil
+	}
+
+	return bson.D{{Key: "_id", Value: id}}, nil
+}
+
+func (repo *repository) idString(value interface{}) string {
+	switch id := value.(type) {
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	case primitive.Binary:
+		if u, err := uuid.FromBytes(id.Data); err == nil {
+			return u.String()
+		}
+		return fmt.Sprint(id)
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+func (repo *repository) generateID(ctx context.Context, object interface{}, collection string) error {
+	obj, ok := object.(Object)
+	if !ok || obj.GetID() != "" {
+		return nil
+	}
+
+	var id string
+	var err error
+
+	switch repo.config.IDType {
+	case UUIDv7:
+		id, err = newUUIDv7()
+
+	case ULID:
+		id, err = newULID()
+
+	case UUID:
+		id = uuid.NewString()
+
+	case Sequence:
+		var seq int64
+		seq, err = repo.NextSequence(ctx, collection)
+		id = strconv.FormatInt(seq, 10)
+
+	default:
+		return nil
 	}
 
-	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return bson.D{{Key: "_id", Value: objectID}}, nil
+	obj.SetID(id)
+	return nil
 }
 
-func (repo *repository) getInsertedID(result *mongo.InsertOneResult) string {
-	if repo.config.IDType == String {
-		return result.InsertedID.(string)
+func newUUIDv7() (string, error) {
+	var b uuid.UUID
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ms[2:])
+
+	b[6] = 0x70 | (b[6] & 0x0f)
+	b[8] = 0x80 | (b[8] & 0x3f)
+
+	return b.String(), nil
+}
+
+func newULID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ms[2:])
+
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+
+	var out strings.Builder
+	for offset := 125; offset >= 0; offset -= 5 {
+		out.WriteByte(crockford[bitsAt(hi, lo, uint(offset))])
+	}
+
+	return out.String(), nil
+}
+
+func bitsAt(hi uint64, lo uint64, offset uint) uint64 {
+	switch {
+	case offset >= 64:
+		return (hi >> (offset - 64)) & 31
+	case offset == 0:
+		return lo & 31
+	default:
+		return (lo>>offset | hi<<(64-offset)) & 31
+	}
+}
+
+func isULID(id string) bool {
+	if len(id) != 26 || id[0] > '7' {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(crockford, id[i]) < 0 {
+			return false
+		}
 	}
 
-	return result.InsertedID.(primitive.ObjectID).Hex()
+	return true
 }
@@ -2,6 +2,12 @@ package mongo
 
 import (
 	"context"
+	"io"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/sse"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const embedTag = "m-embed"
@@ -9,22 +15,65 @@ const embedTag = "m-embed"
 type Repository interface {
 	Create(ctx context.Context, object StorableObject) error
 	Update(ctx context.Context, objectID string, object StorableObject) error
+	Patch(ctx context.Context, objectID string, object StorableObject, fields interface{}) error
+	Delete(ctx context.Context, objectID string, object StorableObject) error
 	GetBy(ctx context.Context, object StorableObject, filters ...Filter) error
 	GetByID(ctx context.Context, objectID string, object StorableObject) error
 	Fetch(ctx context.Context, object StorableObject, out interface{}, filters ...Filter) error
 
+	Search(ctx context.Context, object StorableObject, text string, out interface{}, filters ...Filter) error
+	Near(ctx context.Context, object StorableObject, field string, point Point, maxDistance float64, out interface{}, filters ...Filter) error
+	Within(ctx context.Context, object StorableObject, field string, polygon Polygon, out interface{}, filters ...Filter) error
+	WithinCircle(ctx context.Context, object StorableObject, field string, center Point, radius float64, out interface{}, filters ...Filter) error
+
 	WithTransaction(ctx context.Context, fn func(sc context.Context) error) error
+	CausalSession(ctx context.Context, token string) (context.Context, func(), error)
+	CausalToken(ctx context.Context) (string, error)
 	Aggregate(ctx context.Context, object StorableObject, query string, out interface{}) error
 	Count(ctx context.Context, object StorableObject, filter interface{}) (int64, error)
 
+	Distinct(ctx context.Context, object StorableObject, field string, filters ...Filter) ([]interface{}, error)
+	Exists(ctx context.Context, object StorableObject, filters ...Filter) (bool, error)
+	CountBy(ctx context.Context, object StorableObject, field string, filters ...Filter) ([]GroupCount, error)
+	Sum(ctx context.Context, object StorableObject, field string, filters ...Filter) (float64, error)
+	Avg(ctx context.Context, object StorableObject, field string, filters ...Filter) (float64, error)
+
 	UpdateOne(ctx context.Context, object StorableObject, filter interface{}, update interface{}) (int64, error)
+	Upsert(ctx context.Context, object StorableObject, filters ...Filter) error
+	DeleteBy(ctx context.Context, object StorableObject, filters ...Filter) (int64, error)
 
 	CreateMany(ctx context.Context, obj StorableObject, data []interface{}) error
 	DeleteAll(ctx context.Context, object StorableObject) error
 
 	CreateUniqueIndexes(ctx context.Context, obj StorableObject, values []map[string]int) error
+	EnsureCollections(ctx context.Context, objects ...StorableObject) error
+	CreateTextIndex(ctx context.Context, object StorableObject, fields ...string) error
+	CreateGeoIndex(ctx context.Context, object StorableObject, field string) error
+	NextSequence(ctx context.Context, name string) (int64, error)
+
+	History(ctx context.Context, object StorableObject, objectID string) ([]*HistoryEntry, error)
+
+	UploadFile(ctx context.Context, name string, source io.Reader, metadata interface{}) (*File, error)
+	GetFile(ctx context.Context, fileID string) (*File, error)
+	OpenFile(ctx context.Context, fileID string) (io.ReadCloser, *File, error)
+	DownloadFile(ctx context.Context, fileID string, w io.Writer) (int64, error)
+	DeleteFile(ctx context.Context, fileID string) error
+	ListFiles(ctx context.Context, filters ...Filter) ([]*File, error)
+
+	LoadFixture(ctx context.Context, object StorableObject, path string) (int, error)
+	Import(ctx context.Context, object StorableObject, r io.Reader, format Format) (int, error)
+	Export(ctx context.Context, object StorableObject, w io.Writer, format Format, filters ...Filter) (int, error)
+
+	EnqueueEvent(ctx context.Context, event string, data any) error
+	Dispatcher(publisher sse.Publisher, cfg ...DispatcherConfig) Dispatcher
+	Watcher(publisher sse.Publisher) Watcher
 
 	Preload(ctx context.Context, object any) error
+	Collection(ctx context.Context, name string) (*mongo.Collection, error)
+
+	Health(ctx context.Context) (*Health, error)
+	Monitor(ctx context.Context, interval time.Duration, onChange func(connected bool, err error))
+	Shutdown(ctx context.Context) error
 	Disconnect(ctx context.Context) error
 }
 
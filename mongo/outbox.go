@@ -0,0 +1,271 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/sse"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const outboxCollection = "outbox"
+
+var errNotDelivered = errors.New("event not delivered")
+
+type OutboxStatus string
+
+const (
+	OutboxPending    OutboxStatus = "pending"
+	OutboxProcessing OutboxStatus = "processing"
+	OutboxDelivered  OutboxStatus = "delivered"
+	OutboxFailed     OutboxStatus = "failed"
+)
+
+type OutboxEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Event       string
+	Data        []byte
+	Status      OutboxStatus
+	Attempts    int
+	AvailableAt time.Time
+	LockedUntil time.Time
+	CreatedAt   time.Time
+	DeliveredAt *time.Time
+	LastError   string
+}
+
+type DispatcherConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	LockTimeout time.Duration
+	Backoff     time.Duration
+	// Retention is how long delivered events are kept before a TTL index removes them.
+	Retention time.Duration
+}
+
+func NewDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		Interval:    time.Second,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		LockTimeout: 30 * time.Second,
+		Backoff:     time.Second,
+		Retention:   24 * time.Hour,
+	}
+}
+
+// withDefaults fills the fields a partial config leaves at zero.
+func (c DispatcherConfig) withDefaults() DispatcherConfig {
+	defaults := NewDispatcherConfig()
+
+	if c.Interval <= 0 {
+		c.Interval = defaults.Interval
+	}
+
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaults.BatchSize
+	}
+
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaults.MaxAttempts
+	}
+
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = defaults.LockTimeout
+	}
+
+	if c.Backoff <= 0 {
+		c.Backoff = defaults.Backoff
+	}
+
+	if c.Retention <= 0 {
+		c.Retention = defaults.Retention
+	}
+
+	return c
+}
+
+type Dispatcher interface {
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type dispatcher struct {
+	repo       *repository
+	collection *mongo.Collection
+	publisher  sse.Publisher
+	config     DispatcherConfig
+	cancel     context.CancelFunc
+	wg         *sync.WaitGroup
+}
+
+func (repo *repository) EnqueueEvent(ctx context.Context, event string, data any) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	coll, err := repo.collection(ctx, outboxCollection)
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.InsertOne(ctx, &OutboxEvent{
+		Event:       event,
+		Data:        b,
+		Status:      OutboxPending,
+		AvailableAt: now,
+		CreatedAt:   now,
+	})
+
+	return err
+}
+
+func (repo *repository) Dispatcher(publisher sse.Publisher, cfg ...DispatcherConfig) Dispatcher {
+	config := NewDispatcherConfig()
+	if len(cfg) != 0 {
+		config = cfg[0].withDefaults()
+	}
+
+	d := &dispatcher{repo: repo, publisher: publisher, config: config, wg: &sync.WaitGroup{}}
+	repo.onShutdown(d.Stop)
+
+	return d
+}
+
+// Start dispatches from the outbox of the tenant database in ctx when
+// tenancy is by database, so each tenant needs its own dispatcher.
+func (d *dispatcher) Start(ctx context.Context) error {
+	collection, err := d.repo.collection(ctx, outboxCollection)
+	if err != nil {
+		return err
+	}
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "availableat", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lockeduntil", Value: 1}}},
+		{Keys: bson.D{{Key: "deliveredat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(d.config.Retention.Seconds()))},
+	}
+
+	if _, err = collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
+	}
+
+	d.collection = collection
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			d.dispatch(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (d *dispatcher) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+
+	d.wg.Wait()
+}
+
+func (d *dispatcher) dispatch(ctx context.Context) {
+	for i := 0; i < d.config.BatchSize; i++ {
+		event, err := d.claim(ctx)
+		if err != nil {
+			return
+		}
+
+		d.deliver(ctx, event)
+	}
+}
+
+func (d *dispatcher) claim(ctx context.Context) (*OutboxEvent, error) {
+	now := time.Now().UTC()
+
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "status", Value: OutboxPending}, {Key: "availableat", Value: bson.D{{Key: "$lte", Value: now}}}},
+		bson.D{{Key: "status", Value: OutboxProcessing}, {Key: "lockeduntil", Value: bson.D{{Key: "$lte", Value: now}}}},
+	}}}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: OutboxProcessing}, {Key: "lockeduntil", Value: now.Add(d.config.LockTimeout)}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}}).SetReturnDocument(options.After)
+
+	var event OutboxEvent
+	if err := d.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (d *dispatcher) deliver(ctx context.Context, event *OutboxEvent) {
+	now := time.Now().UTC()
+
+	if d.publisher.TryPublish(event.Event, event.Data) {
+		set := bson.D{{Key: "status", Value: OutboxDelivered}, {Key: "deliveredat", Value: now}}
+		_, _ = d.collection.UpdateByID(ctx, event.ID, bson.D{{Key: "$set", Value: set}})
+		return
+	}
+
+	status := OutboxPending
+	if event.Attempts >= d.config.MaxAttempts {
+		status = OutboxFailed
+	}
+
+	set := bson.D{
+		{Key: "status", Value: status},
+		{Key: "availableat", Value: now.Add(backoff(d.config.Backoff, event.Attempts))},
+		{Key: "lasterror", Value: errNotDelivered.Error()},
+	}
+
+	_, _ = d.collection.UpdateByID(ctx, event.ID, bson.D{{Key: "$set", Value: set}})
+}
+
+func backoff(base time.Duration, attempts int) time.Duration {
+	const maxShift = 16
+
+	if attempts > maxShift {
+		attempts = maxShift
+	}
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return base * time.Duration(1<<(attempts-1))
+}
@@ -3,11 +3,15 @@ package mongo
 import (
 	"context"
 	"reflect"
-
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (repo *repository) Preload(ctx context.Context, obj any) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
 	return repo.search(ctx, obj)
 }
 
@@ -41,6 +45,13 @@ func (repo *repository) search(ctx context.Context, obj interface{}) error {
 			continue
 		}
 
+		if field.Type == fileType || field.Type == reflect.PointerTo(fileType) {
+			if err := repo.fetchFile(ctx, value); err != nil {
+				return err
+			}
+			continue
+		}
+
 		v := value
 		if v.Kind() == reflect.Ptr {
 			v = v.Elem()
@@ -124,20 +135,16 @@ func (repo *repository) fetchObject(ctx context.Context, value reflect.Value, co
 }
 
 func (repo *repository) getObjectByID(ctx context.Context, objectID string, object Object, collection string) error {
-	filter, err := repo.getIDFilter(objectID)
+	raw, err := repo.findByID(ctx, collection, objectID)
 	if err != nil {
 		return err
 	}
 
-	result := repo.database.Collection(collection).FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
-		if err == mongo.ErrNoDocuments {
-			return ErrNoResults
-		}
+	if err = repo.unmarshal(raw, object); err != nil {
 		return err
 	}
 
-	if err = result.Decode(object); err != nil {
+	if err = repo.decrypt(ctx, object); err != nil {
 		return err
 	}
 
@@ -8,9 +8,12 @@ import (
 	"errors"
 	"os"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -20,7 +23,15 @@ var ErrNoResults = errors.New("no documents in result")
 type repository struct {
 	client   *mongo.Client
 	database *mongo.Database
+	registry *bsoncodec.Registry
 	config   *config
+
+	inflight   *tracker
+	closed     chan struct{}
+	closeOnce  *sync.Once
+	mutex      *sync.Mutex
+	background []func()
+	generation *atomic.Uint64
 }
 
 func NewRepository(cfg *config) (Repository, error) {
@@ -34,7 +45,17 @@ func NewRepository(cfg *config) (Repository, error) {
 		}
 	}
 
-	return &repository{client: cfg.Driver.Client, database: cfg.Driver.Database, config: cfg}, nil
+	return &repository{
+		client:     cfg.Driver.Client,
+		database:   cfg.Driver.Database,
+		registry:   newRegistry(),
+		config:     cfg,
+		inflight:   newTracker(),
+		closed:     make(chan struct{}),
+		closeOnce:  &sync.Once{},
+		mutex:      &sync.Mutex{},
+		generation: &atomic.Uint64{},
+	}, nil
 }
 
 func connectMongo(cfg *config) error {
@@ -67,36 +88,72 @@ func connectMongo(cfg *config) error {
 }
 
 func (repo *repository) Create(ctx context.Context, object StorableObject) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	if err = repo.stampCreated(ctx, object); err != nil {
+		return err
+	}
+
+	return repo.create(ctx, object, repo.document)
+}
+
+func (repo *repository) create(ctx context.Context, object StorableObject, document func(ctx context.Context, object interface{}) (interface{}, error)) error {
+	if err := repo.generateID(ctx, object, object.GetCollection()); err != nil {
+		return err
+	}
+
 	if repo.config.ClearEmbeddedFields {
 		if err := repo.clear(object); err != nil {
 			return err
 		}
 	}
 
-	id, err := repo.database.Collection(object.GetCollection()).InsertOne(ctx, object)
+	restore, err := repo.encrypt(ctx, object)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	doc, err := document(ctx, object)
+	if err != nil {
+		return err
+	}
+
+	id, err := coll.InsertOne(ctx, doc)
 	if err != nil {
 		return err
 	}
 
-	object.SetID(repo.getInsertedID(id))
+	object.SetID(repo.idString(id.InsertedID))
 	return nil
 }
 
 func (repo *repository) GetByID(ctx context.Context, objectID string, object StorableObject) error {
-	filter, err := repo.getIDFilter(objectID)
+	ctx, err := repo.begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer repo.end()
 
-	result := repo.database.Collection(object.GetCollection()).FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
-		if err == mongo.ErrNoDocuments {
-			return ErrNoResults
-		}
+	raw, err := repo.findByID(ctx, object.GetCollection(), objectID)
+	if err != nil {
 		return err
 	}
 
-	if err = result.Decode(object); err != nil {
+	if err = repo.unmarshal(raw, object); err != nil {
+		return err
+	}
+
+	if err = repo.decrypt(ctx, object); err != nil {
 		return err
 	}
 
@@ -108,29 +165,83 @@ func (repo *repository) GetByID(ctx context.Context, objectID string, object Sto
 }
 
 func (repo *repository) GetBy(ctx context.Context, object StorableObject, filters ...Filter) error {
-	filter := bson.M{}
-	for _, property := range filters {
-		filter[property.Key] = property.Value
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
 	}
+	defer repo.end()
 
-	result := repo.database.Collection(object.GetCollection()).FindOne(ctx, filter)
-	if err := result.Err(); err != nil {
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	result := coll.FindOne(ctx, scoped)
+	if err = result.Err(); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return ErrNoResults
 		}
 		return err
 	}
 
-	return result.Decode(object)
+	if err = result.Decode(object); err != nil {
+		return err
+	}
+
+	return repo.decrypt(ctx, object)
 }
 
 func (repo *repository) Fetch(ctx context.Context, object StorableObject, out interface{}, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	return repo.find(ctx, object, filter, out)
+}
+
+func (repo *repository) buildFilter(ctx context.Context, object StorableObject, filters []Filter) (bson.M, error) {
+	filters, err := repo.encryptFilters(ctx, object, filters)
+	if err != nil {
+		return nil, err
+	}
+
 	filter := bson.M{}
 	for _, property := range filters {
-		filter[property.Key] = property.Value
+		filter[property.Key] = repo.filterValue(property.Key, property.Value)
 	}
 
-	cursor, err := repo.database.Collection(object.GetCollection()).Find(ctx, filter)
+	return filter, nil
+}
+
+func (repo *repository) find(ctx context.Context, object StorableObject, filter interface{}, out interface{}, opts ...*options.FindOptions) error {
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	cursor, err := coll.Find(ctx, scoped, opts...)
 	if err != nil {
 		return err
 	}
@@ -142,6 +253,10 @@ func (repo *repository) Fetch(ctx context.Context, object StorableObject, out in
 			return err
 		}
 
+		if err = repo.decrypt(ctx, data); err != nil {
+			return err
+		}
+
 		obj := data.(StorableObject)
 		if repo.config.AutoPreload {
 			if err = repo.Preload(ctx, obj); err != nil {
@@ -169,34 +284,189 @@ func (repo *repository) Fetch(ctx context.Context, object StorableObject, out in
 }
 
 func (repo *repository) Update(ctx context.Context, objectID string, object StorableObject) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	if err := repo.stampUpdated(ctx, object); err != nil {
+		return err
+	}
+
 	if repo.config.ClearEmbeddedFields {
 		if err := repo.clear(object); err != nil {
 			return err
 		}
 	}
 
-	filter, err := repo.getIDFilter(objectID)
+	restore, err := repo.encrypt(ctx, object)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	filter, err := repo.filterByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	doc, err := repo.document(ctx, object)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{{Key: "$set", Value: doc}}
+	defer repo.invalidate(ctx, object.GetCollection(), objectID)
+
+	if repo.config.History {
+		_, err = repo.updateWithHistory(ctx, object.GetCollection(), objectID, ActionUpdate, filter, update)
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	return coll.FindOneAndUpdate(ctx, filter, update).Err()
+}
+
+func (repo *repository) Patch(ctx context.Context, objectID string, object StorableObject, fields interface{}) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.filterByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	fields, err = repo.stripTenant(fields)
+	if err != nil {
+		return err
+	}
+
+	fields, err = repo.encryptPatch(ctx, object, fields)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{{Key: "$set", Value: fields}}
+	defer repo.invalidate(ctx, object.GetCollection(), objectID)
+
+	var result bson.M
+	if repo.config.History {
+		result, err = repo.updateWithHistory(ctx, object.GetCollection(), objectID, ActionPatch, filter, update)
+	} else {
+		result, err = repo.findOneAndUpdate(ctx, object.GetCollection(), filter, update)
+	}
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrNoResults
+		}
+		return err
+	}
+
+	b, err := bson.Marshal(result)
 	if err != nil {
 		return err
 	}
 
-	return repo.database.Collection(object.GetCollection()).FindOneAndUpdate(ctx, filter, bson.D{{Key: "$set", Value: object}}).Err()
+	if err = repo.unmarshal(b, object); err != nil {
+		return err
+	}
+
+	return repo.decrypt(ctx, object)
+}
+
+func (repo *repository) findOneAndUpdate(ctx context.Context, collection string, filter interface{}, update interface{}) (bson.M, error) {
+	coll, err := repo.collection(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var result bson.M
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err = coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (repo *repository) Delete(ctx context.Context, objectID string, object StorableObject) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.filterByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	defer repo.invalidate(ctx, object.GetCollection(), objectID)
+
+	if repo.config.History {
+		err = repo.deleteWithHistory(ctx, object.GetCollection(), objectID, filter)
+		if err == mongo.ErrNoDocuments {
+			return ErrNoResults
+		}
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	result, err := coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNoResults
+	}
+
+	return nil
 }
 
 func (repo *repository) WithTransaction(ctx context.Context, fn func(sc context.Context) error) error {
-	session, err := repo.client.StartSession()
+	ctx, err := repo.begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer repo.end()
 
-	defer session.EndSession(ctx)
+	if inTransaction(ctx) {
+		return fn(ctx)
+	}
 
-	if err = session.StartTransaction(); err != nil {
+	session := mongo.SessionFromContext(ctx)
+	if session == nil {
+		var err error
+		if session, err = repo.client.StartSession(); err != nil {
+			return err
+		}
+
+		defer session.EndSession(ctx)
+	}
+
+	if err := session.StartTransaction(); err != nil {
 		return err
 	}
 
+	ctx, pending := withPending(ctx)
+	defer pending.flush()
+
 	return mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		if err = fn(sc); err != nil {
+		if err := fn(sc); err != nil {
 			_ = session.AbortTransaction(sc)
 			return err
 		}
@@ -206,16 +476,32 @@ func (repo *repository) WithTransaction(ctx context.Context, fn func(sc context.
 }
 
 func (repo *repository) Aggregate(ctx context.Context, object StorableObject, query string, out interface{}) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
 	opts := options.Aggregate()
 	opts.SetCollation(&options.Collation{Locale: "en", Strength: 3})
 	opts.SetAllowDiskUse(true)
 
 	var pipeline interface{}
-	if err := bson.UnmarshalExtJSON([]byte(query), true, &pipeline); err != nil {
+	if err = bson.UnmarshalExtJSON([]byte(query), true, &pipeline); err != nil {
 		return err
 	}
 
-	cursor, err := repo.database.Collection(object.GetCollection()).Aggregate(ctx, pipeline, opts)
+	pipeline, err = repo.scopePipeline(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline, opts)
 	if err != nil {
 		return err
 	}
@@ -247,7 +533,7 @@ func (repo *repository) Aggregate(ctx context.Context, object StorableObject, qu
 		return err
 	}
 
-	if err = bson.Unmarshal(b, out); err != nil {
+	if err = repo.unmarshal(b, out); err != nil {
 		return err
 	}
 
@@ -259,44 +545,260 @@ func (repo *repository) Aggregate(ctx context.Context, object StorableObject, qu
 }
 
 func (repo *repository) Count(ctx context.Context, object StorableObject, filter interface{}) (int64, error) {
-	return repo.database.Collection(object.GetCollection()).CountDocuments(ctx, filter)
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return 0, err
+	}
+
+	return coll.CountDocuments(ctx, scoped)
 }
 
 func (repo *repository) UpdateOne(ctx context.Context, object StorableObject, filter interface{}, update interface{}) (int64, error) {
-	result, err := repo.database.Collection(object.GetCollection()).UpdateOne(ctx, filter, update)
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	if err = repo.checkTenantUpdate(update); err != nil {
+		return 0, err
+	}
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
 	if err != nil {
 		return 0, err
 	}
 
+	result, err := coll.UpdateOne(ctx, scoped, update)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.invalidateCollection(ctx, object.GetCollection())
+
 	return result.MatchedCount, nil
 }
 
+func (repo *repository) Upsert(ctx context.Context, object StorableObject, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	if err := repo.generateID(ctx, object, object.GetCollection()); err != nil {
+		return err
+	}
+
+	if repo.config.ClearEmbeddedFields {
+		if err := repo.clear(object); err != nil {
+			return err
+		}
+	}
+
+	restore, err := repo.encrypt(ctx, object)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	doc, err := repo.document(ctx, object)
+	if err != nil {
+		return err
+	}
+
+	fields, err := toDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	set, onInsert := bson.D{}, bson.D{}
+	for _, e := range fields {
+		if e.Key == "_id" {
+			onInsert = append(onInsert, e)
+		} else {
+			set = append(set, e)
+		}
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(onInsert) != 0 {
+		update = append(update, bson.E{Key: "$setOnInsert", Value: onInsert})
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	var result bson.M
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err = coll.FindOneAndUpdate(ctx, scoped, update, opts).Decode(&result); err != nil {
+		return err
+	}
+
+	object.SetID(repo.idString(result["_id"]))
+	repo.invalidate(ctx, object.GetCollection(), object.GetID())
+
+	return nil
+}
+
+func (repo *repository) DeleteBy(ctx context.Context, object StorableObject, filters ...Filter) (int64, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	scoped, err := repo.scope(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := coll.DeleteMany(ctx, scoped)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.invalidateCollection(ctx, object.GetCollection())
+
+	return result.DeletedCount, nil
+}
+
 func (repo *repository) DeleteAll(ctx context.Context, object StorableObject) error {
-	_, err := repo.database.Collection(object.GetCollection()).DeleteMany(ctx, bson.D{})
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	scoped, err := repo.scope(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.DeleteMany(ctx, scoped)
 	if err != nil {
 		return err
 	}
 
+	repo.invalidateCollection(ctx, object.GetCollection())
+
 	return nil
 }
 
+func (repo *repository) Collection(ctx context.Context, name string) (*mongo.Collection, error) {
+	return repo.collection(ctx, name)
+}
+
 func (repo *repository) Disconnect(ctx context.Context) error {
 	return repo.client.Disconnect(ctx)
 }
 
 func (repo *repository) CreateMany(ctx context.Context, obj StorableObject, data []interface{}) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
 	if len(data) == 0 {
 		return nil
 	}
 
-	_, err := repo.database.Collection(obj.GetCollection()).InsertMany(ctx, data)
+	docs := make([]interface{}, 0, len(data))
+	for _, object := range data {
+		if err := repo.generateID(ctx, object, obj.GetCollection()); err != nil {
+			return err
+		}
+
+		if err := repo.stampCreated(ctx, object); err != nil {
+			return err
+		}
+
+		restore, err := repo.encrypt(ctx, object)
+		if err != nil {
+			return err
+		}
+
+		var raw bson.Raw
+		doc, err := repo.document(ctx, object)
+		if err == nil {
+			raw, err = bson.Marshal(doc)
+		}
+
+		restore()
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, raw)
+	}
+
+	coll, err := repo.collection(ctx, obj.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.InsertMany(ctx, docs)
 	return err
 }
 
 func (repo *repository) CreateUniqueIndexes(ctx context.Context, obj StorableObject, values []map[string]int) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
 	indexes := make([]mongo.IndexModel, 0, len(values))
 	for _, v := range values {
 		keys := bson.D{}
+		if repo.config.Tenancy == TenantField {
+			keys = append(keys, bson.E{Key: repo.config.TenantKey, Value: 1})
+		}
+
 		for key, value := range v {
 			keys = append(keys, bson.E{Key: key, Value: value})
 		}
@@ -304,7 +806,12 @@ func (repo *repository) CreateUniqueIndexes(ctx context.Context, obj StorableObj
 		indexes = append(indexes, mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(true)})
 	}
 
-	_, err := repo.database.Collection(obj.GetCollection()).Indexes().CreateMany(ctx, indexes)
+	coll, err := repo.collection(ctx, obj.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.Indexes().CreateMany(ctx, indexes)
 	if !mongo.IsDuplicateKeyError(err) {
 		return err
 	}
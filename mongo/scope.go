@@ -0,0 +1,319 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrUnscopedStage = errors.New("pipeline stage cannot be scoped to the tenant")
+
+func (repo *repository) conditions(ctx context.Context) (bson.D, error) {
+	conditions := bson.D{}
+
+	if repo.config.Tenancy == TenantField {
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, bson.E{Key: repo.config.TenantKey, Value: tenantID})
+	}
+
+	return conditions, nil
+}
+
+func (repo *repository) scope(ctx context.Context, filter interface{}) (interface{}, error) {
+	conditions, err := repo.conditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withConditions(filter, conditions), nil
+}
+
+func withConditions(filter interface{}, conditions bson.D) interface{} {
+	if len(conditions) == 0 {
+		return filter
+	}
+
+	if filter == nil {
+		return conditions
+	}
+
+	if m, ok := filter.(bson.M); ok && !hasAnyKey(m, conditions) {
+		scoped := make(bson.M, len(m)+len(conditions))
+		for key, value := range m {
+			scoped[key] = value
+		}
+
+		for _, condition := range conditions {
+			scoped[condition.Key] = condition.Value
+		}
+
+		return scoped
+	}
+
+	return bson.D{{Key: "$and", Value: bson.A{filter, conditions}}}
+}
+
+func hasAnyKey(m bson.M, conditions bson.D) bool {
+	for _, condition := range conditions {
+		if _, exists := m[condition.Key]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (repo *repository) filterByID(ctx context.Context, objectID string) (interface{}, error) {
+	filter, err := repo.getIDFilter(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.scope(ctx, filter)
+}
+
+func (repo *repository) scopePipeline(ctx context.Context, pipeline interface{}) (interface{}, error) {
+	conditions, err := repo.conditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conditions) == 0 {
+		return pipeline, nil
+	}
+
+	stages, ok := pipeline.(bson.A)
+	if !ok {
+		return nil, errors.New("pipeline must be an array of stages")
+	}
+
+	return scopeStages(stages, conditions)
+}
+
+// scopeStages matches the pipeline's input and every collection it joins
+// against the scope conditions.
+func scopeStages(stages bson.A, conditions bson.D) (bson.A, error) {
+	match := bson.D{{Key: "$match", Value: conditions}}
+	if len(stages) == 0 {
+		return bson.A{match}, nil
+	}
+
+	rest, err := scopeJoins(stages[1:], conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := scopeJoins(stages[:1], conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	name, body := stage(first[0])
+	switch {
+	case name == "$geoNear":
+		geoNear := bson.D{}
+		query := interface{}(conditions)
+		for _, e := range body {
+			if e.Key == "query" {
+				query = bson.D{{Key: "$and", Value: bson.A{e.Value, conditions}}}
+				continue
+			}
+			geoNear = append(geoNear, e)
+		}
+
+		return append(bson.A{bson.D{{Key: name, Value: append(geoNear, bson.E{Key: "query", Value: query})}}}, rest...), nil
+
+	case name == "$changeStream":
+		changes := make(bson.D, 0, len(conditions))
+		for _, condition := range conditions {
+			changes = append(changes, bson.E{Key: "fullDocument." + condition.Key, Value: condition.Value})
+		}
+
+		return append(bson.A{first[0], bson.D{{Key: "$match", Value: changes}}}, rest...), nil
+
+	case name == "$searchMeta":
+		return nil, ErrUnscopedStage
+
+	case searchStages[name]:
+		return append(bson.A{first[0], match}, rest...), nil
+
+	case sourceStages[name]:
+		return append(first, rest...), nil
+	}
+
+	return append(bson.A{match, first[0]}, rest...), nil
+}
+
+func scopeJoins(stages bson.A, conditions bson.D) (bson.A, error) {
+	scoped := make(bson.A, 0, len(stages))
+	for _, value := range stages {
+		name, body := stage(value)
+
+		var err error
+		switch name {
+		case "$lookup":
+			value, err = scopeLookup(body, conditions)
+		case "$unionWith":
+			value, err = scopeUnionWith(value, body, conditions)
+		case "$graphLookup":
+			value = scopeGraphLookup(body, conditions)
+		case "$facet":
+			value, err = scopeFacet(body, conditions)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		scoped = append(scoped, value)
+	}
+
+	return scoped, nil
+}
+
+// scopeLookup adds a pipeline to equality lookups too, which needs MongoDB 5.0.
+func scopeLookup(body bson.D, conditions bson.D) (bson.D, error) {
+	lookup := make(bson.D, 0, len(body)+1)
+
+	var from bool
+	var pipeline bson.A
+	for _, e := range body {
+		switch e.Key {
+		case "from":
+			from = true
+		case "pipeline":
+			pipeline, _ = e.Value.(bson.A)
+			continue
+		}
+		lookup = append(lookup, e)
+	}
+
+	var err error
+	if from {
+		pipeline, err = scopeStages(pipeline, conditions)
+	} else {
+		pipeline, err = scopeJoins(pipeline, conditions)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.D{{Key: "$lookup", Value: append(lookup, bson.E{Key: "pipeline", Value: pipeline})}}, nil
+}
+
+func scopeUnionWith(value interface{}, body bson.D, conditions bson.D) (bson.D, error) {
+	unionWith := make(bson.D, 0, len(body)+1)
+
+	var pipeline bson.A
+	if coll, ok := stageValue(value).(string); ok {
+		unionWith = append(unionWith, bson.E{Key: "coll", Value: coll})
+	}
+
+	for _, e := range body {
+		if e.Key == "pipeline" {
+			pipeline, _ = e.Value.(bson.A)
+			continue
+		}
+		unionWith = append(unionWith, e)
+	}
+
+	pipeline, err := scopeStages(pipeline, conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.D{{Key: "$unionWith", Value: append(unionWith, bson.E{Key: "pipeline", Value: pipeline})}}, nil
+}
+
+func scopeGraphLookup(body bson.D, conditions bson.D) bson.D {
+	graphLookup := make(bson.D, 0, len(body)+1)
+
+	restrict := interface{}(conditions)
+	for _, e := range body {
+		if e.Key == "restrictSearchWithMatch" {
+			restrict = bson.D{{Key: "$and", Value: bson.A{e.Value, conditions}}}
+			continue
+		}
+		graphLookup = append(graphLookup, e)
+	}
+
+	return bson.D{{Key: "$graphLookup", Value: append(graphLookup, bson.E{Key: "restrictSearchWithMatch", Value: restrict})}}
+}
+
+func scopeFacet(body bson.D, conditions bson.D) (bson.D, error) {
+	facet := make(bson.D, 0, len(body))
+	for _, e := range body {
+		pipeline, _ := e.Value.(bson.A)
+
+		scoped, err := scopeJoins(pipeline, conditions)
+		if err != nil {
+			return nil, err
+		}
+
+		facet = append(facet, bson.E{Key: e.Key, Value: scoped})
+	}
+
+	return bson.D{{Key: "$facet", Value: facet}}, nil
+}
+
+// searchStages must open the pipeline, so the scope match goes after them.
+var searchStages = map[string]bool{
+	"$search":       true,
+	"$vectorSearch": true,
+}
+
+// sourceStages produce documents that are not read from the collection and
+// carry no tenant key, so they are left unmatched.
+var sourceStages = map[string]bool{
+	"$collStats":      true,
+	"$indexStats":     true,
+	"$listSessions":   true,
+	"$planCacheStats": true,
+	"$documents":      true,
+}
+
+func stage(value interface{}) (string, bson.D) {
+	var doc bson.D
+	switch v := value.(type) {
+	case bson.D:
+		doc = v
+	case bson.M:
+		for key, val := range v {
+			doc = append(doc, bson.E{Key: key, Value: val})
+		}
+	}
+
+	if len(doc) != 1 {
+		return "", nil
+	}
+
+	body, _ := doc[0].Value.(bson.D)
+	if m, ok := doc[0].Value.(bson.M); ok {
+		for key, val := range m {
+			body = append(body, bson.E{Key: key, Value: val})
+		}
+	}
+
+	return doc[0].Key, body
+}
+
+func stageValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		if len(v) == 1 {
+			return v[0].Value
+		}
+	case bson.M:
+		for _, val := range v {
+			return val
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (repo *repository) Search(ctx context.Context, object StorableObject, text string, out interface{}, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	filter["$text"] = bson.D{{Key: "$search", Value: text}}
+
+	score := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+	if field := repo.config.SearchScoreField; field != "" {
+		score[0].Key = field
+	}
+
+	opts := options.Find().SetSort(score)
+	if repo.config.SearchScoreField != "" {
+		opts.SetProjection(score)
+	}
+
+	return repo.find(ctx, object, filter, out, opts)
+}
+
+func (repo *repository) Near(ctx context.Context, object StorableObject, field string, point Point, maxDistance float64, out interface{}, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	near := bson.D{{Key: "$geometry", Value: point}}
+	if maxDistance > 0 {
+		near = append(near, bson.E{Key: "$maxDistance", Value: maxDistance})
+	}
+
+	filter[field] = bson.D{{Key: "$nearSphere", Value: near}}
+
+	return repo.find(ctx, object, filter, out)
+}
+
+func (repo *repository) Within(ctx context.Context, object StorableObject, field string, polygon Polygon, out interface{}, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	filter[field] = bson.D{{Key: "$geoWithin", Value: bson.D{{Key: "$geometry", Value: polygon}}}}
+
+	return repo.find(ctx, object, filter, out)
+}
+
+func (repo *repository) WithinCircle(ctx context.Context, object StorableObject, field string, center Point, radius float64, out interface{}, filters ...Filter) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	filter, err := repo.buildFilter(ctx, object, filters)
+	if err != nil {
+		return err
+	}
+
+	sphere := bson.A{bson.A{center.Longitude(), center.Latitude()}, radius / earthRadius}
+	filter[field] = bson.D{{Key: "$geoWithin", Value: bson.D{{Key: "$centerSphere", Value: sphere}}}}
+
+	return repo.find(ctx, object, filter, out)
+}
+
+func (repo *repository) CreateTextIndex(ctx context.Context, object StorableObject, fields ...string) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+	}
+
+	return repo.createIndex(ctx, object, keys)
+}
+
+func (repo *repository) CreateGeoIndex(ctx context.Context, object StorableObject, field string) error {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer repo.end()
+
+	return repo.createIndex(ctx, object, bson.D{{Key: field, Value: "2dsphere"}})
+}
+
+func (repo *repository) createIndex(ctx context.Context, object StorableObject, keys bson.D) error {
+	coll, err := repo.collection(ctx, object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys})
+	return err
+}
@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const countersCollection = "counters"
+
+type counter struct {
+	ID  string `bson:"_id"`
+	Seq int64
+}
+
+func (repo *repository) NextSequence(ctx context.Context, name string) (int64, error) {
+	ctx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.end()
+
+	coll, err := repo.collection(ctx, countersCollection)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: name}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: int64(1)}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var c counter
+	if err = coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&c); err != nil {
+		return 0, err
+	}
+
+	return c.Seq, nil
+}
+
+// advanceSequence moves the counter up to value so imported IDs are not
+// handed out again.
+func (repo *repository) advanceSequence(ctx context.Context, name string, value int64) error {
+	coll, err := repo.collection(ctx, countersCollection)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: name}}
+	update := bson.D{{Key: "$max", Value: bson.D{{Key: "seq", Value: value}}}}
+
+	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,170 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNoTenant      = errors.New("no tenant in context")
+	ErrTenantUpdate  = errors.New("update must not modify the tenant key")
+	ErrInvalidTenant = errors.New("tenant id is not a valid database name")
+)
+
+// maxDatabaseName is the server's limit; names must be shorter than 64 bytes.
+const maxDatabaseName = 64
+
+type Tenancy uint
+
+const (
+	NoTenancy Tenancy = iota
+	TenantField
+	TenantDatabase
+)
+
+func (t Tenancy) isValid() bool {
+	return t == NoTenancy || t == TenantField || t == TenantDatabase
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+func (repo *repository) tenant(ctx context.Context) (string, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", ErrNoTenant
+	}
+
+	return tenantID, nil
+}
+
+func (repo *repository) db(ctx context.Context) (*mongo.Database, error) {
+	if repo.config.Tenancy != TenantDatabase {
+		return repo.database, nil
+	}
+
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := repo.database.Name() + "_" + tenantID
+	if len(name) >= maxDatabaseName || strings.ContainsAny(tenantID, "./\\$ \x00") {
+		return nil, ErrInvalidTenant
+	}
+
+	return repo.client.Database(name), nil
+}
+
+func (repo *repository) collection(ctx context.Context, name string) (*mongo.Collection, error) {
+	db, err := repo.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Collection(name, options.Collection().SetRegistry(repo.registry)), nil
+}
+
+// stripTenant drops the tenant key from patched fields so a Patch cannot
+// move a document into another tenant.
+func (repo *repository) stripTenant(fields interface{}) (interface{}, error) {
+	if repo.config.Tenancy != TenantField {
+		return fields, nil
+	}
+
+	doc, err := toDocument(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		if !repo.isTenantKey(e.Key) {
+			stripped = append(stripped, e)
+		}
+	}
+
+	return stripped, nil
+}
+
+// checkTenantUpdate rejects update documents and pipelines that would set,
+// unset, rename or replace the tenant key.
+func (repo *repository) checkTenantUpdate(update interface{}) error {
+	if repo.config.Tenancy != TenantField {
+		return nil
+	}
+
+	b, err := bson.Marshal(bson.D{{Key: "update", Value: update}})
+	if err != nil {
+		return err
+	}
+
+	var wrapped struct{ Update interface{} }
+	if err = bson.Unmarshal(b, &wrapped); err != nil {
+		return err
+	}
+
+	stages, ok := wrapped.Update.(bson.A)
+	if !ok {
+		stages = bson.A{wrapped.Update}
+	}
+
+	for _, stage := range stages {
+		operators, _ := stage.(bson.D)
+		for _, operator := range operators {
+			if repo.touchesTenant(operator.Key, operator.Value) {
+				return ErrTenantUpdate
+			}
+		}
+	}
+
+	return nil
+}
+
+func (repo *repository) touchesTenant(operator string, value interface{}) bool {
+	switch operator {
+	case "$project", "$replaceRoot", "$replaceWith":
+		return true
+	}
+
+	switch v := value.(type) {
+	case bson.D:
+		for _, e := range v {
+			if repo.isTenantKey(e.Key) {
+				return true
+			}
+
+			if target, ok := e.Value.(string); ok && operator == "$rename" && repo.isTenantKey(target) {
+				return true
+			}
+		}
+	case bson.A:
+		for _, e := range v {
+			if field, ok := e.(string); ok && repo.isTenantKey(field) {
+				return true
+			}
+		}
+	case string:
+		return repo.isTenantKey(v)
+	}
+
+	return false
+}
+
+func (repo *repository) isTenantKey(field string) bool {
+	key := repo.config.TenantKey
+	return field == key || strings.HasPrefix(field, key+".")
+}
@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func BinaryUUID(id string) (primitive.Binary, error) {
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return primitive.Binary{}, ErrInvalidID
+	}
+
+	return primitive.Binary{Subtype: bson.TypeBinaryUUID, Data: u[:]}, nil
+}
+
+func newRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	stringCodec := bsoncodec.NewStringCodec()
+
+	registry.RegisterTypeDecoder(reflect.TypeOf(""), bsoncodec.ValueDecoderFunc(
+		func(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+			if vr.Type() != bson.TypeBinary {
+				return stringCodec.DecodeValue(dc, vr, val)
+			}
+
+			data, subtype, err := vr.ReadBinary()
+			if err != nil {
+				return err
+			}
+
+			u, err := uuid.FromBytes(data)
+			if subtype != bson.TypeBinaryUUID || err != nil {
+				return bsoncodec.ValueDecoderError{Name: "UUIDDecodeValue", Kinds: []reflect.Kind{reflect.String}, Received: val}
+			}
+
+			val.SetString(u.String())
+			return nil
+		},
+	))
+
+	return registry
+}
+
+func (repo *repository) unmarshal(data []byte, v interface{}) error {
+	return bson.UnmarshalWithRegistry(repo.registry, data, v)
+}
+
+func (repo *repository) filterValue(key string, value interface{}) interface{} {
+	if repo.config.IDType != UUID || (key != "_id" && !strings.HasSuffix(key, "._id")) {
+		return value
+	}
+
+	id, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	binary, err := BinaryUUID(id)
+	if err != nil {
+		return value
+	}
+
+	return binary
+}
+
+func encodeUUIDs(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		for i, e := range v {
+			if id, ok := e.Value.(string); ok && e.Key == "_id" {
+				if binary, err := BinaryUUID(id); err == nil {
+					v[i].Value = binary
+				}
+				continue
+			}
+
+			v[i].Value = encodeUUIDs(e.Value)
+		}
+
+	case bson.A:
+		for i, e := range v {
+			v[i] = encodeUUIDs(e)
+		}
+	}
+
+	return value
+}
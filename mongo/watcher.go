@@ -0,0 +1,234 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/sse"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const resumeTokensCollection = "resume_tokens"
+
+type Watch struct {
+	Name     string
+	Object   StorableObject
+	Pipeline string
+	Stream   func(event *WatchEvent) string
+	// Decrypt publishes m-encrypt fields in plaintext; by default they are published as stored.
+	Decrypt bool
+}
+
+type WatchEvent struct {
+	Operation string
+	ID        string
+	// TenantID is empty for deletes, which carry no document.
+	TenantID string
+	Object   StorableObject
+}
+
+type Watcher interface {
+	Watch(w Watch) error
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type watcher struct {
+	repo      *repository
+	publisher sse.Publisher
+	watches   []Watch
+	cancel    context.CancelFunc
+	wg        *sync.WaitGroup
+}
+
+type changeEvent struct {
+	OperationType string   `bson:"operationType"`
+	FullDocument  bson.Raw `bson:"fullDocument"`
+	DocumentKey   bson.M   `bson:"documentKey"`
+}
+
+type resumeToken struct {
+	ID    string `bson:"_id"`
+	Token bson.Raw
+}
+
+func (repo *repository) Watcher(publisher sse.Publisher) Watcher {
+	w := &watcher{repo: repo, publisher: publisher, wg: &sync.WaitGroup{}}
+	repo.onShutdown(w.Stop)
+
+	return w
+}
+
+func (w *watcher) Watch(watch Watch) error {
+	if watch.Object == nil {
+		return errors.New("watch object is required")
+	}
+
+	if watch.Name == "" && watch.Pipeline != "" {
+		return errors.New("watch with a pipeline requires a unique name")
+	}
+
+	if watch.Name == "" {
+		watch.Name = watch.Object.GetCollection()
+	}
+
+	for _, other := range w.watches {
+		if other.Name == watch.Name {
+			return errors.New("watch name is already in use: " + watch.Name)
+		}
+	}
+
+	// Events from every tenant share one change stream, so the caller must route them.
+	if watch.Stream == nil && w.repo.config.Tenancy == TenantField {
+		return errors.New("watch stream mapping is required with field tenancy")
+	}
+
+	if watch.Stream == nil {
+		collection := watch.Object.GetCollection()
+		watch.Stream = func(*WatchEvent) string { return collection }
+	}
+
+	w.watches = append(w.watches, watch)
+	return nil
+}
+
+func (w *watcher) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	for _, watch := range w.watches {
+		pipeline := interface{}(bson.A{})
+		if watch.Pipeline != "" {
+			if err := bson.UnmarshalExtJSON([]byte(watch.Pipeline), true, &pipeline); err != nil {
+				w.cancel()
+				return err
+			}
+		}
+
+		w.wg.Add(1)
+		go func(watch Watch, pipeline interface{}) {
+			defer w.wg.Done()
+
+			for ctx.Err() == nil {
+				if err := w.run(ctx, watch, pipeline); err != nil && ctx.Err() == nil {
+					log.Println("[mongo Watcher] change stream failed:", watch.Name, err.Error())
+
+					select {
+					case <-ctx.Done():
+					case <-time.After(time.Second):
+					}
+				}
+			}
+		}(watch, pipeline)
+	}
+
+	return nil
+}
+
+func (w *watcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+}
+
+func (w *watcher) run(ctx context.Context, watch Watch, pipeline interface{}) error {
+	coll, err := w.repo.collection(ctx, watch.Object.GetCollection())
+	if err != nil {
+		return err
+	}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	token, err := w.loadToken(ctx, watch.Name)
+	if err != nil {
+		return err
+	}
+
+	if token != nil {
+		opts.SetStartAfter(token)
+	}
+
+	stream, err := coll.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change changeEvent
+		if err = stream.Decode(&change); err != nil {
+			return err
+		}
+
+		event, err := w.decode(ctx, watch, &change)
+		if err != nil {
+			return err
+		}
+
+		if err = w.publisher.PublishJSON(watch.Stream(event), event); err != nil {
+			return err
+		}
+
+		if err = w.saveToken(ctx, watch.Name, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+
+	return stream.Err()
+}
+
+func (w *watcher) decode(ctx context.Context, watch Watch, change *changeEvent) (*WatchEvent, error) {
+	event := &WatchEvent{Operation: change.OperationType, ID: w.repo.idString(change.DocumentKey["_id"])}
+
+	if change.FullDocument == nil {
+		return event, nil
+	}
+
+	if w.repo.config.Tenancy == TenantField {
+		event.TenantID, _ = change.FullDocument.Lookup(w.repo.config.TenantKey).StringValueOK()
+	}
+
+	object := reflect.New(reflect.TypeOf(watch.Object).Elem()).Interface().(StorableObject)
+	if err := w.repo.unmarshal(change.FullDocument, object); err != nil {
+		return nil, err
+	}
+
+	if watch.Decrypt {
+		if err := w.repo.decrypt(ctx, object); err != nil {
+			return nil, err
+		}
+	}
+
+	event.ID = object.GetID()
+	event.Object = object
+	return event, nil
+}
+
+func (w *watcher) loadToken(ctx context.Context, name string) (bson.Raw, error) {
+	var token resumeToken
+
+	err := w.repo.database.Collection(resumeTokensCollection).FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return token.Token, nil
+}
+
+func (w *watcher) saveToken(ctx context.Context, name string, token bson.Raw) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := w.repo.database.Collection(resumeTokensCollection).ReplaceOne(ctx, bson.D{{Key: "_id", Value: name}}, &resumeToken{ID: name, Token: token}, opts)
+	return err
+}
@@ -0,0 +1,146 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	url      string
+	ttl      time.Duration
+	cooldown time.Duration
+
+	mutex   *sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func newJWKS(url string, ttl time.Duration) *jwks {
+	return &jwks{
+		url:      url,
+		ttl:      ttl,
+		cooldown: time.Minute,
+		mutex:    &sync.Mutex{},
+		keys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+func (j *jwks) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	since := time.Since(j.fetched)
+
+	key, ok := j.keys[kid]
+	if ok && since < j.ttl {
+		return key, nil
+	}
+
+	if !ok && !j.fetched.IsZero() && since < j.cooldown {
+		return nil, ErrUnknownKey
+	}
+
+	if err := j.refresh(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+
+		return nil, err
+	}
+
+	if key, ok = j.keys[kid]; !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (j *jwks) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := getJSON(ctx, j.url, &set, nil); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		key, err := k.rsa()
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	j.keys = keys
+	j.fetched = time.Now()
+	return nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid rsa exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func getJSON(ctx context.Context, url string, out interface{}, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("invalid response")
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -8,4 +8,5 @@ type Discord struct {
 	Email    string `json:"email"`
 	MFA      bool   `json:"mfa_enabled"`
 	Verified bool   `json:"verified"`
+	Locale   string `json:"locale"`
 }
@@ -0,0 +1,17 @@
+package models
+
+type GitHub struct {
+	ID            int64  `json:"id"`
+	Login         string `json:"login"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"-"`
+	AvatarURL     string `json:"avatar_url"`
+}
+
+type GitHubEmail struct {
+	Email      string `json:"email"`
+	Primary    bool   `json:"primary"`
+	Verified   bool   `json:"verified"`
+	Visibility string `json:"visibility"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type GitLab struct {
+	ID          int64   `json:"id"`
+	Username    string  `json:"username"`
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	AvatarURL   string  `json:"avatar_url"`
+	ConfirmedAt *string `json:"confirmed_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+type LinkedIn struct {
+	Subject       string         `json:"sub"`
+	Name          string         `json:"name"`
+	GivenName     string         `json:"given_name"`
+	FamilyName    string         `json:"family_name"`
+	Picture       string         `json:"picture"`
+	Email         string         `json:"email"`
+	EmailVerified bool           `json:"email_verified"`
+	Locale        LinkedInLocale `json:"locale"`
+}
+
+type LinkedInLocale struct {
+	Country  string `json:"country"`
+	Language string `json:"language"`
+}
@@ -6,4 +6,7 @@ type Microsoft struct {
 	Surname     string `json:"surname"`
 	DisplayName string `json:"displayName"`
 	Email       string `json:"userPrincipalName"`
+	Mail        string `json:"mail"`
+
+	PreferredLanguage string `json:"preferredLanguage"`
 }
@@ -0,0 +1,13 @@
+package models
+
+type Twitch struct {
+	Data []TwitchUser `json:"data"`
+}
+
+type TwitchUser struct {
+	ID              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	Email           string `json:"email"`
+	ProfileImageURL string `json:"profile_image_url"`
+}
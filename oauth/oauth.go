@@ -9,15 +9,34 @@ import (
 
 type OAuth[T any] interface {
 	Login() (string, error)
+	LoginWithPayload(payload []byte) (string, error)
 	Callback(r *http.Request) (*oauth2.Token, error)
+	CallbackWithPayload(r *http.Request) (*oauth2.Token, []byte, error)
 	GetUser(ctx context.Context, token string) (*T, error)
+	GetProfile(ctx context.Context, token string) (*Profile, error)
+	Name() string
+	TokenSource(ctx context.Context, store TokenStore, userID string) oauth2.TokenSource
+	Client(ctx context.Context, store TokenStore, userID string) *http.Client
+	SetStates(states StateGenerator) OAuth[T]
+	SetPKCE(enabled bool) OAuth[T]
+	SetOIDC(oidc OIDC) OAuth[T]
+	SetName(name string) OAuth[T]
+	SetMapper(mapper Mapper[T]) OAuth[T]
+	SetHook(hook UserHook[T]) OAuth[T]
+	SetHeader(key string, value string) OAuth[T]
 }
 
+// UserHook runs after the user is decoded, to complete it with further
+// provider calls or reject it.
+type UserHook[T any] func(ctx context.Context, token string, user *T) error
+
 func New[T any](config oauth2.Config, userURL string, userAuthType TokenType) OAuth[T] {
 	return &service[T]{
 		config:       config,
 		states:       NewGenerator(),
 		userURL:      userURL,
 		userAuthType: userAuthType,
+		header:       http.Header{},
+		refreshes:    newKeyedMutex(),
 	}
 }
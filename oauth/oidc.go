@@ -0,0 +1,264 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	nonceLabel    = "nonce"
+	claimsKey     = "id_token_claims"
+	clockSkew     = time.Minute
+	googleIssuer  = "https://accounts.google.com"
+)
+
+var (
+	ErrInvalidIDToken   = errors.New("invalid id token")
+	ErrUnsupportedAlg   = errors.New("unsupported id token algorithm")
+	ErrInvalidSignature = errors.New("invalid id token signature")
+	ErrInvalidIssuer    = errors.New("invalid id token issuer")
+	ErrInvalidAudience  = errors.New("invalid id token audience")
+	ErrIDTokenExpired   = errors.New("id token expired")
+	ErrIDTokenIssuedAt  = errors.New("id token issued in the future")
+	ErrInvalidNonce     = errors.New("invalid id token nonce")
+	ErrInvalidDiscovery = errors.New("invalid discovery document")
+)
+
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+
+	*a = many
+	return nil
+}
+
+func (a Audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      Audience `json:"aud"`
+	AuthorizedBy  string   `json:"azp"`
+	Expiry        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	TenantID      string   `json:"tid"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+	Locale        string   `json:"locale"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+type OIDC interface {
+	Discovery(ctx context.Context) (*Discovery, error)
+	Verify(ctx context.Context, idToken string, nonce string) (*Claims, error)
+}
+
+type oidc struct {
+	issuer   string
+	clientID string
+
+	mutex     *sync.Mutex
+	discovery *Discovery
+	keys      *jwks
+}
+
+func NewOIDC(issuer string, clientID string) OIDC {
+	return &oidc{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientID: clientID,
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func ClaimsFromToken(token *oauth2.Token) (*Claims, bool) {
+	if token == nil {
+		return nil, false
+	}
+
+	claims, ok := token.Extra(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// withClaims keeps the id_token and scope extras, as oauth2.Token.WithExtra
+// replaces the raw token response.
+func withClaims(token *oauth2.Token, claims *Claims) *oauth2.Token {
+	extra := map[string]interface{}{claimsKey: claims}
+	for _, key := range []string{"id_token", "scope"} {
+		if value := token.Extra(key); value != nil {
+			extra[key] = value
+		}
+	}
+
+	return token.WithExtra(extra)
+}
+
+func (o *oidc) Discovery(ctx context.Context) (*Discovery, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := getJSON(ctx, o.issuer+discoveryPath, &discovery, nil); err != nil {
+		return nil, err
+	}
+
+	if discovery.JWKSURI == "" || !issuerMatches(o.issuer, strings.TrimSuffix(discovery.Issuer, "/")) {
+		return nil, ErrInvalidDiscovery
+	}
+
+	o.discovery = &discovery
+	o.keys = newJWKS(discovery.JWKSURI, time.Hour)
+	return o.discovery, nil
+}
+
+func (o *oidc) Verify(ctx context.Context, idToken string, nonce string) (*Claims, error) {
+	discovery, err := o.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err = decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	if header.Alg != "RS256" {
+		return nil, ErrUnsupportedAlg
+	}
+
+	key, err := o.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims := &Claims{Raw: payload}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !issuerEquals(strings.ReplaceAll(discovery.Issuer, "{tenantid}", claims.TenantID), claims.Issuer) {
+		return nil, ErrInvalidIssuer
+	}
+
+	if !claims.Audience.contains(o.clientID) {
+		return nil, ErrInvalidAudience
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != "" && claims.AuthorizedBy != o.clientID {
+		return nil, ErrInvalidAudience
+	}
+
+	now := time.Now()
+	if now.Add(-clockSkew).Unix() >= claims.Expiry {
+		return nil, ErrIDTokenExpired
+	}
+
+	if claims.IssuedAt > now.Add(clockSkew).Unix() {
+		return nil, ErrIDTokenIssuedAt
+	}
+
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, ErrInvalidNonce
+	}
+
+	return claims, nil
+}
+
+// issuerEquals accepts Google's scheme-less "accounts.google.com", which it
+// documents as a valid iss alongside the https form.
+func issuerEquals(expected string, actual string) bool {
+	return actual == expected || (expected == googleIssuer && actual == strings.TrimPrefix(googleIssuer, "https://"))
+}
+
+// issuerMatches also accepts multi-tenant templates such as Microsoft's
+// ".../{tenantid}/v2.0" discovered from ".../common/v2.0".
+func issuerMatches(configured string, discovered string) bool {
+	if configured == discovered {
+		return true
+	}
+
+	before, after, templated := strings.Cut(discovered, "{tenantid}")
+	if !templated || !strings.HasPrefix(configured, before) || !strings.HasSuffix(configured, after) {
+		return false
+	}
+
+	tenant := strings.TrimSuffix(strings.TrimPrefix(configured, before), after)
+	return tenant != "" && !strings.Contains(tenant, "/")
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,244 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClientID = "client"
+
+type testKey struct {
+	kid     string
+	private *rsa.PrivateKey
+}
+
+func newTestKey(t *testing.T, kid string) *testKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKey{kid: kid, private: private}
+}
+
+func (k *testKey) jwk() jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: k.kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(k.private.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.private.E)).Bytes()),
+	}
+}
+
+func (k *testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": k.kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, k.private, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// fakeIssuer serves a discovery document under path and a JWKS whose keys
+// can be swapped to simulate a rollover.
+type fakeIssuer struct {
+	server *httptest.Server
+	mutex  *sync.Mutex
+	keys   []*testKey
+}
+
+func newFakeIssuer(t *testing.T, path string, issuer func(base string) string, keys ...*testKey) *fakeIssuer {
+	t.Helper()
+
+	f := &fakeIssuer{mutex: &sync.Mutex{}, keys: keys}
+	f.server = fakeProvider(t, map[string]http.HandlerFunc{
+		path + discoveryPath: func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(Discovery{Issuer: issuer(f.server.URL), JWKSURI: f.server.URL + "/keys"})
+		},
+		"/keys": func(w http.ResponseWriter, r *http.Request) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+
+			set := struct {
+				Keys []jwk `json:"keys"`
+			}{}
+			for _, key := range f.keys {
+				set.Keys = append(set.Keys, key.jwk())
+			}
+
+			_ = json.NewEncoder(w).Encode(set)
+		},
+	})
+
+	return f
+}
+
+func (f *fakeIssuer) rotate(keys ...*testKey) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.keys = keys
+}
+
+func testClaims(issuer string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   issuer,
+		"sub":   "subject",
+		"aud":   testClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": "nonce",
+	}
+}
+
+func TestVerifyAcceptsValidToken(t *testing.T) {
+	key := newTestKey(t, "k1")
+	issuer := newFakeIssuer(t, "", func(base string) string { return base }, key)
+
+	claims, err := NewOIDC(issuer.server.URL, testClientID).Verify(context.Background(), key.sign(t, testClaims(issuer.server.URL)), "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "subject" {
+		t.Fatalf("unexpected subject %q", claims.Subject)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	key := newTestKey(t, "k1")
+	issuer := newFakeIssuer(t, "", func(base string) string { return base }, key)
+	forged := &testKey{kid: key.kid, private: newTestKey(t, "other").private}
+
+	tests := []struct {
+		name   string
+		signer *testKey
+		modify func(claims map[string]interface{})
+		want   error
+	}{
+		{name: "bad signature", signer: forged, want: ErrInvalidSignature},
+		{name: "wrong audience", modify: func(c map[string]interface{}) { c["aud"] = "someone-else" }, want: ErrInvalidAudience},
+		{name: "wrong nonce", modify: func(c map[string]interface{}) { c["nonce"] = "replayed" }, want: ErrInvalidNonce},
+		{name: "wrong issuer", modify: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, want: ErrInvalidIssuer},
+		{name: "expired", modify: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, want: ErrIDTokenExpired},
+		{name: "issued in the future", modify: func(c map[string]interface{}) { c["iat"] = time.Now().Add(time.Hour).Unix() }, want: ErrIDTokenIssuedAt},
+	}
+
+	verifier := NewOIDC(issuer.server.URL, testClientID)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := testClaims(issuer.server.URL)
+			if test.modify != nil {
+				test.modify(claims)
+			}
+
+			signer := key
+			if test.signer != nil {
+				signer = test.signer
+			}
+
+			if _, err := verifier.Verify(context.Background(), signer.sign(t, claims), "nonce"); err != test.want {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func TestVerifyFollowsKeyRollover(t *testing.T) {
+	oldKey, newKey := newTestKey(t, "old"), newTestKey(t, "new")
+	issuer := newFakeIssuer(t, "", func(base string) string { return base }, oldKey)
+
+	verifier := NewOIDC(issuer.server.URL, testClientID)
+	claims := testClaims(issuer.server.URL)
+
+	if _, err := verifier.Verify(context.Background(), oldKey.sign(t, claims), "nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.rotate(newKey)
+
+	// Unknown kids only trigger a refetch once the cooldown has passed.
+	verifier.(*oidc).keys.fetched = time.Now().Add(-2 * time.Minute)
+
+	if _, err := verifier.Verify(context.Background(), newKey.sign(t, claims), "nonce"); err != nil {
+		t.Fatalf("expected the rotated key to verify, got %v", err)
+	}
+
+	if _, err := verifier.Verify(context.Background(), oldKey.sign(t, claims), "nonce"); err != ErrUnknownKey {
+		t.Fatalf("expected the retired key to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyMicrosoftTenantTemplate(t *testing.T) {
+	key := newTestKey(t, "k1")
+	issuer := newFakeIssuer(t, "/common/v2.0", func(base string) string { return base + "/{tenantid}/v2.0" }, key)
+
+	verifier := NewOIDC(issuer.server.URL+"/common/v2.0", testClientID)
+
+	claims := testClaims(issuer.server.URL + "/tenant-a/v2.0")
+	claims["tid"] = "tenant-a"
+
+	verified, err := verifier.Verify(context.Background(), key.sign(t, claims), "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verified.TenantID != "tenant-a" {
+		t.Fatalf("unexpected tenant %q", verified.TenantID)
+	}
+
+	claims["tid"] = "tenant-b"
+	if _, err = verifier.Verify(context.Background(), key.sign(t, claims), "nonce"); err != ErrInvalidIssuer {
+		t.Fatalf("expected a tid that does not match iss to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyRejectsMismatchedDiscoveryIssuer(t *testing.T) {
+	key := newTestKey(t, "k1")
+	issuer := newFakeIssuer(t, "", func(string) string { return "https://evil.example.com" }, key)
+
+	if _, err := NewOIDC(issuer.server.URL, testClientID).Discovery(context.Background()); err != ErrInvalidDiscovery {
+		t.Fatalf("expected %v, got %v", ErrInvalidDiscovery, err)
+	}
+}
+
+func TestIssuerEqualsAcceptsSchemelessGoogle(t *testing.T) {
+	if !issuerEquals(googleIssuer, "accounts.google.com") || !issuerEquals(googleIssuer, googleIssuer) {
+		t.Fatal("expected both Google issuer forms to be accepted")
+	}
+
+	if issuerEquals("https://login.example.com", "login.example.com") {
+		t.Fatal("expected scheme-less issuers to be rejected for other providers")
+	}
+}
@@ -0,0 +1,140 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/pedrobarbosak/go-utils/oauth/models"
+)
+
+var ErrNoMapper = errors.New("no profile mapper")
+
+type Profile struct {
+	Provider      string          `json:"provider"`
+	Subject       string          `json:"subject"`
+	Email         string          `json:"email"`
+	EmailVerified bool            `json:"emailVerified"`
+	Name          string          `json:"name"`
+	AvatarURL     string          `json:"avatarUrl"`
+	Locale        string          `json:"locale"`
+	Raw           json.RawMessage `json:"raw"`
+}
+
+type Mapper[T any] func(user *T) *Profile
+
+func MapGoogle(user *models.Google) *Profile {
+	return &Profile{
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.VerifiedEmail,
+		Name:          user.Name,
+		AvatarURL:     user.Picture,
+		Locale:        user.Locale,
+	}
+}
+
+func MapFacebook(user *models.Facebook) *Profile {
+	return &Profile{
+		Subject:   user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		AvatarURL: user.Picture.Data.URL,
+	}
+}
+
+func MapMicrosoft(user *models.Microsoft) *Profile {
+	email := user.Mail
+	if email == "" {
+		email = user.Email
+	}
+
+	return &Profile{
+		Subject: user.ID,
+		Email:   email,
+		Name:    user.DisplayName,
+		Locale:  user.PreferredLanguage,
+	}
+}
+
+func MapDiscord(user *models.Discord) *Profile {
+	profile := &Profile{
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.Verified,
+		Name:          user.Name,
+		Locale:        user.Locale,
+	}
+
+	if profile.Name == "" {
+		profile.Name = user.Username
+	}
+
+	if user.AvatarID != "" {
+		profile.AvatarURL = fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.png", user.ID, user.AvatarID)
+	}
+
+	return profile
+}
+
+func MapGitHub(user *models.GitHub) *Profile {
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	return &Profile{
+		Subject:       strconv.FormatInt(user.ID, 10),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          name,
+		AvatarURL:     user.AvatarURL,
+	}
+}
+
+func MapGitLab(user *models.GitLab) *Profile {
+	name := user.Name
+	if name == "" {
+		name = user.Username
+	}
+
+	return &Profile{
+		Subject:       strconv.FormatInt(user.ID, 10),
+		Email:         user.Email,
+		EmailVerified: user.ConfirmedAt != nil,
+		Name:          name,
+		AvatarURL:     user.AvatarURL,
+	}
+}
+
+func MapTwitch(user *models.Twitch) *Profile {
+	if len(user.Data) == 0 {
+		return &Profile{}
+	}
+
+	data := user.Data[0]
+	return &Profile{
+		Subject:       data.ID,
+		Email:         data.Email,
+		EmailVerified: data.Email != "",
+		Name:          data.DisplayName,
+		AvatarURL:     data.ProfileImageURL,
+	}
+}
+
+func MapLinkedIn(user *models.LinkedIn) *Profile {
+	locale := user.Locale.Language
+	if user.Locale.Country != "" {
+		locale += "-" + user.Locale.Country
+	}
+
+	return &Profile{
+		Subject:       user.Subject,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		AvatarURL:     user.Picture,
+		Locale:        locale,
+	}
+}
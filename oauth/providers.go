@@ -1,12 +1,27 @@
 package oauth
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
 	"github.com/pedrobarbosak/go-utils/oauth/models"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/endpoints"
 )
 
+const (
+	githubAPI   = "https://api.github.com"
+	twitchAPI   = "https://api.twitch.tv"
+	linkedinAPI = "https://api.linkedin.com"
+)
+
+var ErrNoTwitchUser = errors.New("twitch returned no user")
+
+// NewGoogle calls the userinfo endpoint only; chain SetOIDC(GoogleOIDC(clientID))
+// and SetPKCE(true) to validate the id_token as well.
 func NewGoogle(clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.Google] {
 	return New[models.Google](oauth2.Config{
 		ClientID:     clientID,
@@ -16,7 +31,17 @@ func NewGoogle(clientID string, secret string, redirectURL string, scopes ...str
 		Scopes:       scopes,
 	}, "https://www.googleapis.com/oauth2/v2/userinfo",
 		AccessTokenQueryParam,
-	)
+	).
+		SetName("google").
+		SetMapper(MapGoogle)
+}
+
+func GoogleOIDC(clientID string) OIDC {
+	return NewOIDC(googleIssuer, clientID)
+}
+
+func MicrosoftOIDC(tenant string, clientID string) OIDC {
+	return NewOIDC("https://login.microsoftonline.com/"+tenant+"/v2.0", clientID)
 }
 
 func NewFacebook(clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.Facebook] {
@@ -28,9 +53,13 @@ func NewFacebook(clientID string, secret string, redirectURL string, scopes ...s
 		Scopes:       scopes,
 	}, "https://graph.facebook.com/me?fields=id,name,email,picture",
 		AccessTokenQueryParam,
-	)
+	).
+		SetName("facebook").
+		SetMapper(MapFacebook)
 }
 
+// NewMicrosoft calls Graph only; chain SetOIDC(MicrosoftOIDC(tenant, clientID))
+// and SetPKCE(true) to validate the id_token as well.
 func NewMicrosoft(clientID string, secret string, redirectURL string, tenant string, scopes ...string) OAuth[models.Microsoft] {
 	return New[models.Microsoft](oauth2.Config{
 		ClientID:     clientID,
@@ -40,7 +69,9 @@ func NewMicrosoft(clientID string, secret string, redirectURL string, tenant str
 		Scopes:       scopes,
 	}, "https://graph.microsoft.com/v1.0/me",
 		AuthorizationHeader,
-	)
+	).
+		SetName("microsoft").
+		SetMapper(MapMicrosoft)
 }
 
 func NewDiscord(clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.Discord] {
@@ -55,5 +86,146 @@ func NewDiscord(clientID string, secret string, redirectURL string, scopes ...st
 		Scopes:      scopes,
 	}, "https://discord.com/api/v10/users/@me",
 		AuthorizationHeader,
-	)
+	).
+		SetName("discord").
+		SetMapper(MapDiscord)
+}
+
+// NewGitHub uses github.com when baseURL is empty, otherwise a GitHub
+// Enterprise server with its API under /api/v3.
+func NewGitHub(baseURL string, clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.GitHub] {
+	endpoint, apiURL := endpoints.GitHub, githubAPI
+	if baseURL != "" {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		endpoint = oauth2.Endpoint{
+			AuthURL:  baseURL + "/login/oauth/authorize",
+			TokenURL: baseURL + "/login/oauth/access_token",
+		}
+		apiURL = baseURL + "/api/v3"
+	}
+
+	return New[models.GitHub](oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Endpoint:     endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}, apiURL+"/user",
+		AuthorizationHeader,
+	).
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHook(githubPrimaryEmail(apiURL)).
+		SetName("github").
+		SetMapper(MapGitHub)
+}
+
+func NewGitLab(baseURL string, clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.GitLab] {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return New[models.GitLab](oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth/authorize",
+			TokenURL: baseURL + "/oauth/token",
+		},
+		RedirectURL: redirectURL,
+		Scopes:      scopes,
+	}, baseURL+"/api/v4/user",
+		AuthorizationHeader,
+	).
+		SetPKCE(true).
+		SetName("gitlab").
+		SetMapper(MapGitLab)
+}
+
+// NewTwitch uses Twitch's id and api hosts when baseURL is empty, otherwise
+// serves both from baseURL.
+func NewTwitch(baseURL string, clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.Twitch] {
+	endpoint, apiURL := endpoints.Twitch, twitchAPI
+	if baseURL != "" {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		endpoint = oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth2/authorize",
+			TokenURL: baseURL + "/oauth2/token",
+		}
+		apiURL = baseURL
+	}
+
+	return New[models.Twitch](oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Endpoint:     endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}, apiURL+"/helix/users",
+		AuthorizationHeader,
+	).
+		SetHeader("Client-Id", clientID).
+		SetHook(requireTwitchUser).
+		SetName("twitch").
+		SetMapper(MapTwitch)
+}
+
+// NewLinkedIn uses LinkedIn's www and api hosts when baseURL is empty,
+// otherwise serves both from baseURL.
+func NewLinkedIn(baseURL string, clientID string, secret string, redirectURL string, scopes ...string) OAuth[models.LinkedIn] {
+	endpoint, apiURL := endpoints.LinkedIn, linkedinAPI
+	if baseURL != "" {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		endpoint = oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth/v2/authorization",
+			TokenURL: baseURL + "/oauth/v2/accessToken",
+		}
+		apiURL = baseURL
+	}
+
+	return New[models.LinkedIn](oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Endpoint:     endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}, apiURL+"/v2/userinfo",
+		AuthorizationHeader,
+	).
+		SetName("linkedin").
+		SetMapper(MapLinkedIn)
+}
+
+// githubPrimaryEmail replaces the public profile email, which may be empty or
+// unverified, with the verified primary one from /user/emails. Tokens without
+// the user:email scope get a 404 there and keep the unverified public email.
+func githubPrimaryEmail(apiURL string) UserHook[models.GitHub] {
+	return func(ctx context.Context, token string, user *models.GitHub) error {
+		header := http.Header{}
+		header.Set("Authorization", "Bearer "+token)
+
+		var emails []models.GitHubEmail
+		if err := getJSON(ctx, apiURL+"/user/emails", &emails, header); err != nil {
+			return nil
+		}
+
+		for _, email := range emails {
+			if email.Primary && email.Verified {
+				user.Email = email.Email
+				user.EmailVerified = true
+				return nil
+			}
+		}
+
+		return nil
+	}
+}
+
+func requireTwitchUser(_ context.Context, _ string, user *models.Twitch) error {
+	if len(user.Data) == 0 {
+		return ErrNoTwitchUser
+	}
+
+	return nil
 }
@@ -0,0 +1,201 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testToken = "test-token"
+
+func fakeProvider(t *testing.T, routes map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for path, handler := range routes {
+		mux.HandleFunc(path, handler)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func bearer(next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func respond(body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func tokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": testToken,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+	})
+}
+
+func TestGitHubProfileUsesVerifiedPrimaryEmail(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/api/v3/user": bearer(respond(`{"id":42,"login":"octocat","email":"public@example.com","avatar_url":"https://avatars/42"}`)),
+		"/api/v3/user/emails": bearer(respond(`[
+			{"email":"secondary@example.com","primary":false,"verified":true},
+			{"email":"primary@example.com","primary":true,"verified":true}
+		]`)),
+	})
+
+	profile, err := NewGitHub(server.URL, "client", "secret", "http://localhost/callback").GetProfile(context.Background(), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Provider != "github" || profile.Subject != "42" || profile.Name != "octocat" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	if profile.Email != "primary@example.com" || !profile.EmailVerified {
+		t.Fatalf("expected verified primary email, got %q (verified=%v)", profile.Email, profile.EmailVerified)
+	}
+}
+
+func TestGitHubProfileFallsBackWithoutEmailScope(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/api/v3/user":        bearer(respond(`{"id":42,"login":"octocat","name":"Octo Cat","email":"public@example.com"}`)),
+		"/api/v3/user/emails": func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+	})
+
+	profile, err := NewGitHub(server.URL, "client", "secret", "http://localhost/callback").GetProfile(context.Background(), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Name != "Octo Cat" || profile.Email != "public@example.com" || profile.EmailVerified {
+		t.Fatalf("expected unverified public email, got %+v", profile)
+	}
+}
+
+func TestGitHubLoginAndCallback(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/login/oauth/access_token": tokenEndpoint,
+	})
+
+	github := NewGitHub(server.URL, "client", "secret", "http://localhost/callback")
+
+	login, err := github.LoginWithPayload([]byte("/dashboard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(login, server.URL+"/login/oauth/authorize?") {
+		t.Fatalf("unexpected login url: %s", login)
+	}
+
+	u, err := url.Parse(login)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callback := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(u.Query().Get("state")), nil)
+
+	token, payload, err := github.CallbackWithPayload(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.AccessToken != testToken || string(payload) != "/dashboard" {
+		t.Fatalf("unexpected callback result: %q %q", token.AccessToken, payload)
+	}
+}
+
+func TestGitLabProfile(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/api/v4/user": bearer(respond(`{"id":7,"username":"tanuki","email":"tanuki@example.com","avatar_url":"https://avatars/7","confirmed_at":"2023-01-01T00:00:00Z"}`)),
+	})
+
+	gitlab := NewGitLab(server.URL+"/", "client", "secret", "http://localhost/callback")
+
+	login, err := gitlab.Login()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(login, server.URL+"/oauth/authorize?") {
+		t.Fatalf("unexpected login url: %s", login)
+	}
+
+	profile, err := gitlab.GetProfile(context.Background(), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Provider != "gitlab" || profile.Subject != "7" || profile.Name != "tanuki" || !profile.EmailVerified {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+}
+
+func TestTwitchProfileSendsClientID(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/helix/users": bearer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Client-Id") != "client" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			respond(`{"data":[{"id":"99","login":"streamer","display_name":"Streamer","email":"streamer@example.com","profile_image_url":"https://avatars/99"}]}`)(w, r)
+		}),
+	})
+
+	profile, err := NewTwitch(server.URL, "client", "secret", "http://localhost/callback").GetProfile(context.Background(), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Provider != "twitch" || profile.Subject != "99" || profile.Name != "Streamer" || profile.AvatarURL != "https://avatars/99" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+}
+
+func TestTwitchProfileWithoutUser(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/helix/users": bearer(respond(`{"data":[]}`)),
+	})
+
+	_, err := NewTwitch(server.URL, "client", "secret", "http://localhost/callback").GetProfile(context.Background(), testToken)
+	if !errors.Is(err, ErrNoTwitchUser) {
+		t.Fatalf("expected ErrNoTwitchUser, got %v", err)
+	}
+}
+
+func TestLinkedInProfile(t *testing.T) {
+	server := fakeProvider(t, map[string]http.HandlerFunc{
+		"/v2/userinfo": bearer(respond(`{"sub":"abc","name":"Jane Doe","picture":"https://avatars/abc","email":"jane@example.com","email_verified":true,"locale":{"country":"US","language":"en"}}`)),
+	})
+
+	profile, err := NewLinkedIn(server.URL, "client", "secret", "http://localhost/callback").GetProfile(context.Background(), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Provider != "linkedin" || profile.Subject != "abc" || !profile.EmailVerified || profile.Locale != "en-US" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+}
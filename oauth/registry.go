@@ -0,0 +1,131 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	apierrors "github.com/pedrobarbosak/go-utils/api-errors"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+type Provider interface {
+	Name() string
+	LoginWithPayload(payload []byte) (string, error)
+	CallbackWithPayload(r *http.Request) (*oauth2.Token, []byte, error)
+	GetProfile(ctx context.Context, token string) (*Profile, error)
+}
+
+type CallbackFunc func(ctx *gin.Context, profile *Profile, token *oauth2.Token, payload []byte) error
+
+type PayloadFunc func(ctx *gin.Context) ([]byte, error)
+
+type Registry interface {
+	Register(providers ...Provider) Registry
+	Get(name string) (Provider, bool)
+	SetPayload(payload PayloadFunc) Registry
+	Mount(router gin.IRouter, callback CallbackFunc)
+}
+
+type registry struct {
+	mutex     *sync.RWMutex
+	providers map[string]Provider
+	payload   PayloadFunc
+}
+
+func NewRegistry(providers ...Provider) Registry {
+	r := &registry{
+		mutex:     &sync.RWMutex{},
+		providers: make(map[string]Provider),
+	}
+
+	return r.Register(providers...)
+}
+
+func (r *registry) Register(providers ...Provider) Registry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, provider := range providers {
+		r.providers[provider.Name()] = provider
+	}
+
+	return r
+}
+
+func (r *registry) Get(name string) (Provider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+func (r *registry) SetPayload(payload PayloadFunc) Registry {
+	r.payload = payload
+	return r
+}
+
+func (r *registry) Mount(router gin.IRouter, callback CallbackFunc) {
+	group := router.Group("/auth/:provider")
+	group.GET("/login", r.login)
+	group.GET("/callback", r.callback(callback))
+}
+
+func (r *registry) login(ctx *gin.Context) {
+	provider, ok := r.Get(ctx.Param("provider"))
+	if !ok {
+		_ = ctx.Error(apierrors.NewNotFound("unknown oauth provider:", ctx.Param("provider")))
+		return
+	}
+
+	var payload []byte
+	if r.payload != nil {
+		var err error
+		if payload, err = r.payload(ctx); err != nil {
+			_ = ctx.Error(apierrors.NewInput("invalid oauth login payload:", err))
+			return
+		}
+	}
+
+	url, err := provider.LoginWithPayload(payload)
+	if err != nil {
+		_ = ctx.Error(apierrors.NewFatal("failed to start oauth login:", err))
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, url)
+}
+
+func (r *registry) callback(callback CallbackFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		provider, ok := r.Get(ctx.Param("provider"))
+		if !ok {
+			_ = ctx.Error(apierrors.NewNotFound("unknown oauth provider:", ctx.Param("provider")))
+			return
+		}
+
+		if reason := ctx.Query("error"); reason != "" {
+			_ = ctx.Error(apierrors.NewUnauthorized("oauth login denied:", reason, ctx.Query("error_description")))
+			return
+		}
+
+		token, payload, err := provider.CallbackWithPayload(ctx.Request)
+		if err != nil {
+			_ = ctx.Error(apierrors.NewUnauthorized("failed to complete oauth callback:", err))
+			return
+		}
+
+		profile, err := provider.GetProfile(ctx.Request.Context(), token.AccessToken)
+		if err != nil {
+			_ = ctx.Error(apierrors.NewFatal("failed to get oauth profile:", err))
+			return
+		}
+
+		if err = callback(ctx, profile, token, payload); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
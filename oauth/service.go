@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 
 	"golang.org/x/oauth2"
@@ -17,66 +16,212 @@ type service[T any] struct {
 	states       StateGenerator
 	userURL      string
 	userAuthType TokenType
+	pkce         bool
+	oidc         OIDC
+	name         string
+	mapper       Mapper[T]
+	hook         UserHook[T]
+	header       http.Header
+	refreshes    *keyedMutex
 }
 
+const pkceLabel = "pkce"
+
 func (s *service[T]) Login() (string, error) {
-	state, err := s.states.New()
+	return s.LoginWithPayload(nil)
+}
+
+func (s *service[T]) LoginWithPayload(payload []byte) (string, error) {
+	state, err := s.states.NewWithPayload(payload)
 	if err != nil {
 		return "", err
 	}
 
-	return s.config.AuthCodeURL(state), nil
+	var opts []oauth2.AuthCodeOption
+	if s.pkce {
+		verifier, err := s.states.Derive(state, pkceLabel)
+		if err != nil {
+			return "", err
+		}
+
+		opts = append(opts, oauth2.S256ChallengeOption(verifier))
+	}
+
+	if s.oidc != nil {
+		nonce, err := s.states.Derive(state, nonceLabel)
+		if err != nil {
+			return "", err
+		}
+
+		opts = append(opts, oauth2.SetAuthURLParam(nonceLabel, nonce))
+	}
+
+	return s.config.AuthCodeURL(state, opts...), nil
 }
 
 func (s *service[T]) Callback(r *http.Request) (*oauth2.Token, error) {
+	token, _, err := s.CallbackWithPayload(r)
+	return token, err
+}
+
+func (s *service[T]) CallbackWithPayload(r *http.Request) (*oauth2.Token, []byte, error) {
 	state := r.FormValue("state")
-	if err := s.states.Validate(state); err != nil {
-		return nil, err
+	payload, err := s.states.ValidateWithPayload(state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if s.pkce {
+		verifier, err := s.states.Derive(state, pkceLabel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts = append(opts, oauth2.VerifierOption(verifier))
 	}
 
 	code := r.FormValue("code")
-	token, err := s.config.Exchange(r.Context(), code)
+	token, err := s.config.Exchange(r.Context(), code, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if s.oidc != nil {
+		idToken, ok := token.Extra("id_token").(string)
+		if !ok || idToken == "" {
+			return nil, nil, ErrInvalidIDToken
+		}
+
+		nonce, err := s.states.Derive(state, nonceLabel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		claims, err := s.oidc.Verify(r.Context(), idToken, nonce)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		token = withClaims(token, claims)
 	}
 
-	return token, nil
+	return token, payload, nil
 }
 
 func (s *service[T]) GetUser(ctx context.Context, token string) (*T, error) {
-	req, err := http.NewRequest("GET", s.userURL, nil)
+	obj, _, err := s.getUser(ctx, token)
+	return obj, err
+}
+
+func (s *service[T]) GetProfile(ctx context.Context, token string) (*Profile, error) {
+	if s.mapper == nil {
+		return nil, ErrNoMapper
+	}
+
+	obj, data, err := s.getUser(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
+	profile := s.mapper(obj)
+	profile.Provider = s.name
+	profile.Raw = data
+
+	return profile, nil
+}
+
+func (s *service[T]) Name() string {
+	return s.name
+}
+
+func (s *service[T]) SetStates(states StateGenerator) OAuth[T] {
+	s.states = states
+	return s
+}
+
+func (s *service[T]) SetPKCE(enabled bool) OAuth[T] {
+	s.pkce = enabled
+	return s
+}
+
+func (s *service[T]) SetOIDC(oidc OIDC) OAuth[T] {
+	s.oidc = oidc
+
+	for _, scope := range s.config.Scopes {
+		if scope == "openid" {
+			return s
+		}
+	}
+
+	s.config.Scopes = append(append([]string{}, s.config.Scopes...), "openid")
+	return s
+}
+
+func (s *service[T]) SetName(name string) OAuth[T] {
+	s.name = name
+	return s
+}
+
+func (s *service[T]) SetMapper(mapper Mapper[T]) OAuth[T] {
+	s.mapper = mapper
+	return s
+}
+
+func (s *service[T]) SetHook(hook UserHook[T]) OAuth[T] {
+	s.hook = hook
+	return s
+}
+
+func (s *service[T]) SetHeader(key string, value string) OAuth[T] {
+	s.header.Set(key, value)
+	return s
+}
+
+func (s *service[T]) getUser(ctx context.Context, token string) (*T, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.userURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	s.prepareAuthenticationRequest(req, token)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	defer resp.Body.Close()
+
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	log.Println(string(data))
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("invalid response")
+		return nil, nil, errors.New("invalid response")
 	}
 
 	var obj T
 	if err = json.Unmarshal(data, &obj); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &obj, nil
+	if s.hook != nil {
+		if err = s.hook(ctx, token, &obj); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &obj, data, nil
 }
 
 func (s *service[T]) prepareAuthenticationRequest(req *http.Request, token string) {
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+
 	switch s.userAuthType {
 
 	case AuthorizationHeader:
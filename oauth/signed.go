@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoStateKeys   = errors.New("no state keys")
+	ErrShortStateKey = errors.New("state key must have at least 32 bytes")
+)
+
+type signedState struct {
+	Nonce   []byte `json:"n"`
+	Expires int64  `json:"e"`
+	Payload []byte `json:"p,omitempty"`
+}
+
+type signedGenerator struct {
+	keys    [][]byte
+	timeout time.Duration
+}
+
+// NewSignedGenerator issues self-contained states signed with the first key.
+// Older keys are only used for validation, so they can be rotated out once
+// states signed with them have expired.
+func NewSignedGenerator(timeout time.Duration, keys ...[]byte) (StateGenerator, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoStateKeys
+	}
+
+	for _, key := range keys {
+		if len(key) < sha256.Size {
+			return nil, ErrShortStateKey
+		}
+	}
+
+	return &signedGenerator{
+		keys:    keys,
+		timeout: timeout,
+	}, nil
+}
+
+func (s *signedGenerator) New() (string, error) {
+	return s.NewWithPayload(nil)
+}
+
+func (s *signedGenerator) NewWithPayload(payload []byte) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(signedState{
+		Nonce:   nonce,
+		Expires: time.Now().Add(s.timeout).Unix(),
+		Payload: payload,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(b)
+	return body + "." + base64.RawURLEncoding.EncodeToString(sign(s.keys[0], body)), nil
+}
+
+func (s *signedGenerator) Validate(state string) error {
+	_, err := s.ValidateWithPayload(state)
+	return err
+}
+
+func (s *signedGenerator) ValidateWithPayload(state string) ([]byte, error) {
+	body, _, err := s.verify(state)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+
+	var decoded signedState
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		return nil, ErrInvalidState
+	}
+
+	if time.Now().Unix() > decoded.Expires {
+		return nil, ErrStateTimeout
+	}
+
+	return decoded.Payload, nil
+}
+
+func (s *signedGenerator) Derive(state string, label string) (string, error) {
+	_, key, err := s.verify(state)
+	if err != nil {
+		return "", err
+	}
+
+	return derive(key, state, label), nil
+}
+
+func (s *signedGenerator) verify(state string) (string, []byte, error) {
+	body, signature, ok := strings.Cut(state, ".")
+	if !ok {
+		return "", nil, ErrInvalidState
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", nil, ErrInvalidState
+	}
+
+	for _, key := range s.keys {
+		if hmac.Equal(mac, sign(key, body)) {
+			return body, key, nil
+		}
+	}
+
+	return "", nil, ErrInvalidState
+}
+
+func sign(key []byte, body string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(body))
+	return h.Sum(nil)
+}
@@ -1,36 +1,68 @@
 package oauth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"sync"
 	"time"
 )
 
+var (
+	ErrInvalidState = errors.New("invalid state")
+	ErrStateTimeout = errors.New("timout state")
+)
+
 type StateGenerator interface {
 	New() (string, error)
+	NewWithPayload(payload []byte) (string, error)
 	Validate(state string) error
+	ValidateWithPayload(state string) ([]byte, error)
+	Derive(state string, label string) (string, error)
+}
+
+type entry struct {
+	created time.Time
+	payload []byte
 }
 
 type generator struct {
+	key     []byte
+	keyErr  error
 	mutex   *sync.Mutex
-	states  map[string]time.Time
+	states  map[string]entry
 	timeout time.Duration
 }
 
 func NewGenerator() StateGenerator {
+	key := make([]byte, sha256.Size)
+	_, err := rand.Read(key)
+
 	return &generator{
+		key:     key,
+		keyErr:  err,
 		mutex:   &sync.Mutex{},
-		states:  make(map[string]time.Time),
+		states:  make(map[string]entry),
 		timeout: time.Minute * 3,
 	}
 }
 
 func (s *generator) New() (string, error) {
+	return s.NewWithPayload(nil)
+}
+
+func (s *generator) NewWithPayload(payload []byte) (string, error) {
+	if s.keyErr != nil {
+		return "", s.keyErr
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	s.evict()
+
 	var err error
 	for i := 0; i < 10; i++ {
 		var state string
@@ -39,7 +71,7 @@ func (s *generator) New() (string, error) {
 			continue
 		}
 
-		s.states[state] = time.Now()
+		s.states[state] = entry{created: time.Now(), payload: payload}
 		return state, nil
 	}
 
@@ -47,21 +79,43 @@ func (s *generator) New() (string, error) {
 }
 
 func (s *generator) Validate(state string) error {
+	_, err := s.ValidateWithPayload(state)
+	return err
+}
+
+func (s *generator) ValidateWithPayload(state string) ([]byte, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	t, ok := s.states[state]
+	e, ok := s.states[state]
 	if !ok {
-		return errors.New("invalid state")
+		return nil, ErrInvalidState
 	}
 
 	delete(s.states, state)
 
-	if time.Now().After(t.Add(s.timeout)) {
-		return errors.New("timout state")
+	if time.Now().After(e.created.Add(s.timeout)) {
+		return nil, ErrStateTimeout
+	}
+
+	return e.payload, nil
+}
+
+func (s *generator) Derive(state string, label string) (string, error) {
+	if s.keyErr != nil {
+		return "", s.keyErr
 	}
 
-	return nil
+	return derive(s.key, state, label), nil
+}
+
+func (s *generator) evict() {
+	now := time.Now()
+	for state, e := range s.states {
+		if now.After(e.created.Add(s.timeout)) {
+			delete(s.states, state)
+		}
+	}
 }
 
 func (s *generator) new() (string, error) {
@@ -73,3 +127,11 @@ func (s *generator) new() (string, error) {
 
 	return base64.URLEncoding.EncodeToString(b), nil
 }
+
+func derive(key []byte, state string, label string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(label))
+	h.Write([]byte{0})
+	h.Write([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
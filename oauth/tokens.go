@@ -0,0 +1,171 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+type TokenStore interface {
+	Get(ctx context.Context, provider string, userID string) (*oauth2.Token, error)
+	Save(ctx context.Context, provider string, userID string, token *oauth2.Token) error
+	Delete(ctx context.Context, provider string, userID string) error
+}
+
+type memoryStore struct {
+	mutex  *sync.RWMutex
+	tokens map[string]oauth2.Token
+}
+
+func NewMemoryTokenStore() TokenStore {
+	return &memoryStore{
+		mutex:  &sync.RWMutex{},
+		tokens: make(map[string]oauth2.Token),
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, provider string, userID string) (*oauth2.Token, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	token, ok := s.tokens[provider+"/"+userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+
+	return &token, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, provider string, userID string, token *oauth2.Token) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens[provider+"/"+userID] = oauth2.Token{
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, provider string, userID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.tokens, provider+"/"+userID)
+	return nil
+}
+
+type keyedMutex struct {
+	mutex *sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mutex *sync.Mutex
+	refs  int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{mutex: &sync.Mutex{}, locks: make(map[string]*keyedLock)}
+}
+
+func (k *keyedMutex) lock(key string) func() {
+	k.mutex.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{mutex: &sync.Mutex{}}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mutex.Unlock()
+
+	l.mutex.Lock()
+
+	return func() {
+		l.mutex.Unlock()
+
+		k.mutex.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mutex.Unlock()
+	}
+}
+
+type storedSource struct {
+	ctx       context.Context
+	config    *oauth2.Config
+	store     TokenStore
+	refreshes *keyedMutex
+	provider  string
+	userID    string
+
+	mutex   *sync.Mutex
+	current *oauth2.Token
+}
+
+func (s *service[T]) TokenSource(ctx context.Context, store TokenStore, userID string) oauth2.TokenSource {
+	return &storedSource{
+		ctx:       ctx,
+		config:    &s.config,
+		store:     store,
+		refreshes: s.refreshes,
+		provider:  s.name,
+		userID:    userID,
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (s *service[T]) Client(ctx context.Context, store TokenStore, userID string) *http.Client {
+	return oauth2.NewClient(ctx, s.TokenSource(ctx, store, userID))
+}
+
+// Token re-reads the store before refreshing and serialises refreshes per
+// provider and user, as providers such as Discord rotate refresh tokens and
+// a second refresh with the old one fails with invalid_grant.
+func (s *storedSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.current.Valid() {
+		return s.current, nil
+	}
+
+	unlock := s.refreshes.lock(s.provider + "/" + s.userID)
+	defer unlock()
+
+	stored, err := s.store.Get(s.ctx, s.provider, s.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Valid() {
+		s.current = stored
+		return stored, nil
+	}
+
+	token, err := s.config.TokenSource(s.ctx, stored).Token()
+	if err != nil {
+		if latest, getErr := s.store.Get(s.ctx, s.provider, s.userID); getErr == nil && latest.Valid() {
+			s.current = latest
+			return latest, nil
+		}
+
+		return nil, err
+	}
+
+	if err = s.store.Save(s.ctx, s.provider, s.userID, token); err != nil {
+		return nil, err
+	}
+
+	s.current = token
+	return token, nil
+}
@@ -0,0 +1,131 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/mongo"
+
+	"golang.org/x/oauth2"
+)
+
+const tokensCollection = "oauth_tokens"
+
+type storedToken interface {
+	mongo.StorableObject
+	token() *oauth2.Token
+	set(provider string, userID string, token *oauth2.Token)
+}
+
+type tokenDocument struct {
+	ID           string `bson:"_id,omitempty"`
+	Provider     string
+	UserID       string
+	AccessToken  string
+	RefreshToken string
+	TokenType    string
+	Expiry       time.Time
+}
+
+func (d *tokenDocument) GetID() string         { return d.ID }
+func (d *tokenDocument) SetID(id string)       { d.ID = id }
+func (d *tokenDocument) GetCollection() string { return tokensCollection }
+
+func (d *tokenDocument) token() *oauth2.Token {
+	return &oauth2.Token{AccessToken: d.AccessToken, TokenType: d.TokenType, RefreshToken: d.RefreshToken, Expiry: d.Expiry}
+}
+
+func (d *tokenDocument) set(provider string, userID string, token *oauth2.Token) {
+	d.Provider, d.UserID = provider, userID
+	d.AccessToken, d.TokenType, d.RefreshToken, d.Expiry = token.AccessToken, token.TokenType, token.RefreshToken, token.Expiry
+}
+
+type encryptedTokenDocument struct {
+	ID           string `bson:"_id,omitempty"`
+	Provider     string
+	UserID       string
+	AccessToken  string `m-encrypt:""`
+	RefreshToken string `m-encrypt:""`
+	TokenType    string
+	Expiry       time.Time
+}
+
+func (d *encryptedTokenDocument) GetID() string         { return d.ID }
+func (d *encryptedTokenDocument) SetID(id string)       { d.ID = id }
+func (d *encryptedTokenDocument) GetCollection() string { return tokensCollection }
+
+func (d *encryptedTokenDocument) token() *oauth2.Token {
+	return &oauth2.Token{AccessToken: d.AccessToken, TokenType: d.TokenType, RefreshToken: d.RefreshToken, Expiry: d.Expiry}
+}
+
+func (d *encryptedTokenDocument) set(provider string, userID string, token *oauth2.Token) {
+	d.Provider, d.UserID = provider, userID
+	d.AccessToken, d.TokenType, d.RefreshToken, d.Expiry = token.AccessToken, token.TokenType, token.RefreshToken, token.Expiry
+}
+
+type mongoStore struct {
+	repo      mongo.Repository
+	encrypted bool
+}
+
+// NewMongoTokenStore keeps tokens in the oauth_tokens collection. Encrypted
+// stores require the repository to be configured with a KeyProvider.
+func NewMongoTokenStore(ctx context.Context, repo mongo.Repository, encrypted bool) (TokenStore, error) {
+	s := &mongoStore{repo: repo, encrypted: encrypted}
+
+	index := []map[string]int{{"provider": 1, "userid": 1}}
+	if err := repo.CreateUniqueIndexes(ctx, s.document(), index); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *mongoStore) Get(ctx context.Context, provider string, userID string) (*oauth2.Token, error) {
+	doc, err := s.find(ctx, provider, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.token(), nil
+}
+
+func (s *mongoStore) Save(ctx context.Context, provider string, userID string, token *oauth2.Token) error {
+	doc := s.document()
+	doc.set(provider, userID, token)
+
+	return s.repo.Upsert(ctx, doc, s.filters(provider, userID)...)
+}
+
+func (s *mongoStore) Delete(ctx context.Context, provider string, userID string) error {
+	_, err := s.repo.DeleteBy(ctx, s.document(), s.filters(provider, userID)...)
+	return err
+}
+
+func (s *mongoStore) find(ctx context.Context, provider string, userID string) (storedToken, error) {
+	doc := s.document()
+
+	err := s.repo.GetBy(ctx, doc, s.filters(provider, userID)...)
+	if errors.Is(err, mongo.ErrNoResults) {
+		return nil, ErrTokenNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (s *mongoStore) document() storedToken {
+	if s.encrypted {
+		return &encryptedTokenDocument{}
+	}
+
+	return &tokenDocument{}
+}
+
+func (s *mongoStore) filters(provider string, userID string) []mongo.Filter {
+	return []mongo.Filter{{Key: "provider", Value: provider}, {Key: "userid", Value: userID}}
+}
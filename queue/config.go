@@ -0,0 +1,40 @@
+package queue
+
+import "time"
+
+type Config struct {
+	Collection        string
+	VisibilityTimeout time.Duration
+	MaxAttempts       int
+	Backoff           time.Duration
+	MaxBackoff        time.Duration
+}
+
+func NewConfig() Config {
+	return Config{
+		Collection:        "jobs",
+		VisibilityTimeout: time.Minute,
+		MaxAttempts:       5,
+		Backoff:           5 * time.Second,
+		MaxBackoff:        time.Hour,
+	}
+}
+
+type WorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+}
+
+func NewWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Concurrency:  4,
+		PollInterval: time.Second,
+	}
+}
+
+type EnqueueOptions struct {
+	Priority    int
+	Delay       time.Duration
+	RunAt       time.Time
+	MaxAttempts int
+}
@@ -0,0 +1,20 @@
+package queue
+
+import "context"
+
+type Handler func(ctx context.Context, job *Job) error
+
+type Queue interface {
+	Enqueue(ctx context.Context, name string, payload any, opts ...EnqueueOptions) (*Job, error)
+	Claim(ctx context.Context, names ...string) (*Job, error)
+	Extend(ctx context.Context, job *Job) error
+	Complete(ctx context.Context, job *Job) error
+	Fail(ctx context.Context, job *Job, cause error) error
+	Get(ctx context.Context, jobID string) (*Job, error)
+}
+
+type Worker interface {
+	Handle(name string, handler Handler)
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusDead      Status = "dead"
+)
+
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string
+	Payload     []byte
+	Priority    int
+	Status      Status
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedUntil time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (job *Job) Decode(v any) error {
+	return json.Unmarshal(job.Payload, v)
+}
@@ -0,0 +1,249 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/pedrobarbosak/go-utils/mongo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNoJobs     = errors.New("no jobs available")
+	ErrJobNotHeld = errors.New("job is no longer held")
+)
+
+type service struct {
+	collection *driver.Collection
+	config     Config
+}
+
+func New(ctx context.Context, repo mongo.Repository, cfg ...Config) (Queue, error) {
+	config := NewConfig()
+	if len(cfg) != 0 {
+		config = cfg[0]
+	}
+
+	collection, err := repo.Collection(ctx, config.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []driver.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "runat", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lockeduntil", Value: 1}}},
+	}
+
+	if _, err = collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, err
+	}
+
+	return &service{collection: collection, config: config}, nil
+}
+
+func (s *service) Enqueue(ctx context.Context, name string, payload any, opts ...EnqueueOptions) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	job := &Job{
+		Name:        name,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: s.config.MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if len(opts) != 0 {
+		opt := opts[0]
+		job.Priority = opt.Priority
+
+		if !opt.RunAt.IsZero() {
+			job.RunAt = opt.RunAt.UTC()
+		}
+
+		if opt.Delay > 0 {
+			job.RunAt = job.RunAt.Add(opt.Delay)
+		}
+
+		if opt.MaxAttempts > 0 {
+			job.MaxAttempts = opt.MaxAttempts
+		}
+	}
+
+	result, err := s.collection.InsertOne(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return job, nil
+}
+
+func (s *service) Claim(ctx context.Context, names ...string) (*Job, error) {
+	now := time.Now().UTC()
+
+	if err := s.buryExpired(ctx, now, names); err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "status", Value: StatusPending}, {Key: "runat", Value: bson.D{{Key: "$lte", Value: now}}}},
+		bson.D{
+			{Key: "status", Value: StatusRunning},
+			{Key: "lockeduntil", Value: bson.D{{Key: "$lte", Value: now}}},
+			{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{"$attempts", "$maxattempts"}}}},
+		},
+	}}}
+
+	if len(names) != 0 {
+		filter = append(filter, bson.E{Key: "name", Value: bson.D{{Key: "$in", Value: names}}})
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: StatusRunning},
+			{Key: "lockeduntil", Value: now.Add(s.config.VisibilityTimeout)},
+			{Key: "updatedat", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "runat", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	if err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		if err == driver.ErrNoDocuments {
+			return nil, ErrNoJobs
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// buryExpired dead-letters running jobs whose lock expired on their last
+// attempt, as a job that crashed its worker never reaches Fail.
+func (s *service) buryExpired(ctx context.Context, now time.Time, names []string) error {
+	filter := bson.D{
+		{Key: "status", Value: StatusRunning},
+		{Key: "lockeduntil", Value: bson.D{{Key: "$lte", Value: now}}},
+		{Key: "$expr", Value: bson.D{{Key: "$gte", Value: bson.A{"$attempts", "$maxattempts"}}}},
+	}
+
+	if len(names) != 0 {
+		filter = append(filter, bson.E{Key: "name", Value: bson.D{{Key: "$in", Value: names}}})
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: StatusDead},
+		{Key: "lasterror", Value: "visibility timeout expired on last attempt"},
+		{Key: "updatedat", Value: now},
+	}}}
+
+	_, err := s.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (s *service) Extend(ctx context.Context, job *Job) error {
+	lockedUntil := time.Now().UTC().Add(s.config.VisibilityTimeout)
+
+	if err := s.transition(ctx, job, bson.D{{Key: "lockeduntil", Value: lockedUntil}}); err != nil {
+		return err
+	}
+
+	job.LockedUntil = lockedUntil
+	return nil
+}
+
+func (s *service) Complete(ctx context.Context, job *Job) error {
+	if err := s.transition(ctx, job, bson.D{{Key: "status", Value: StatusCompleted}}); err != nil {
+		return err
+	}
+
+	job.Status = StatusCompleted
+	return nil
+}
+
+func (s *service) Fail(ctx context.Context, job *Job, cause error) error {
+	status := StatusPending
+	if job.Attempts >= job.MaxAttempts {
+		status = StatusDead
+	}
+
+	runAt := time.Now().UTC().Add(s.backoff(job.Attempts))
+
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+
+	set := bson.D{{Key: "status", Value: status}, {Key: "runat", Value: runAt}, {Key: "lasterror", Value: message}}
+	if err := s.transition(ctx, job, set); err != nil {
+		return err
+	}
+
+	job.Status = status
+	job.RunAt = runAt
+	job.LastError = message
+	return nil
+}
+
+func (s *service) Get(ctx context.Context, jobID string) (*Job, error) {
+	id, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err = s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&job); err != nil {
+		if err == driver.ErrNoDocuments {
+			return nil, mongo.ErrNoResults
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *service) transition(ctx context.Context, job *Job, set bson.D) error {
+	filter := bson.D{{Key: "_id", Value: job.ID}, {Key: "status", Value: StatusRunning}, {Key: "attempts", Value: job.Attempts}}
+	set = append(set, bson.E{Key: "updatedat", Value: time.Now().UTC()})
+
+	result, err := s.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrJobNotHeld
+	}
+
+	return nil
+}
+
+func (s *service) backoff(attempts int) time.Duration {
+	delay := s.config.Backoff
+	for i := 1; i < attempts && delay < s.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > s.config.MaxBackoff {
+		return s.config.MaxBackoff
+	}
+
+	return delay
+}
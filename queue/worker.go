@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrNoHandlers = errors.New("worker has no handlers")
+
+type worker struct {
+	queue    Queue
+	config   WorkerConfig
+	handlers map[string]Handler
+
+	stop   context.CancelFunc
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+func NewWorker(queue Queue, cfg ...WorkerConfig) Worker {
+	config := NewWorkerConfig()
+	if len(cfg) != 0 {
+		config = cfg[0]
+	}
+
+	return &worker{
+		queue:    queue,
+		config:   config,
+		handlers: make(map[string]Handler),
+		wg:       &sync.WaitGroup{},
+	}
+}
+
+func (w *worker) Handle(name string, handler Handler) {
+	w.handlers[name] = handler
+}
+
+func (w *worker) Start(ctx context.Context) error {
+	if len(w.handlers) == 0 {
+		return ErrNoHandlers
+	}
+
+	names := make([]string, 0, len(w.handlers))
+	for name := range w.handlers {
+		names = append(names, name)
+	}
+
+	var stopCtx context.Context
+	stopCtx, w.stop = context.WithCancel(ctx)
+
+	var jobCtx context.Context
+	jobCtx, w.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	for i := 0; i < w.config.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.loop(stopCtx, jobCtx, names)
+	}
+
+	return nil
+}
+
+func (w *worker) Stop(ctx context.Context) error {
+	if w.stop == nil {
+		return nil
+	}
+
+	w.stop()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (w *worker) loop(stopCtx context.Context, jobCtx context.Context, names []string) {
+	defer w.wg.Done()
+
+	for stopCtx.Err() == nil {
+		job, err := w.queue.Claim(stopCtx, names...)
+		if err != nil {
+			if !errors.Is(err, ErrNoJobs) && stopCtx.Err() == nil {
+				log.Println("[queue Worker] failed to claim job:", err.Error())
+			}
+
+			select {
+			case <-stopCtx.Done():
+			case <-time.After(w.config.PollInterval):
+			}
+			continue
+		}
+
+		w.process(jobCtx, job)
+	}
+}
+
+func (w *worker) process(ctx context.Context, job *Job) {
+	handler, ok := w.handlers[job.Name]
+	if !ok {
+		_ = w.queue.Fail(ctx, job, fmt.Errorf("no handler for job: %s", job.Name))
+		return
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.heartbeat(heartbeatCtx, job)
+	}()
+
+	err := run(ctx, handler, job)
+	stopHeartbeat()
+	<-stopped
+
+	if err != nil {
+		if failErr := w.queue.Fail(ctx, job, err); failErr != nil {
+			log.Println("[queue Worker] failed to mark job as failed:", job.ID.Hex(), failErr.Error())
+		}
+		return
+	}
+
+	if err = w.queue.Complete(ctx, job); err != nil {
+		log.Println("[queue Worker] failed to complete job:", job.ID.Hex(), err.Error())
+	}
+}
+
+func (w *worker) heartbeat(ctx context.Context, job *Job) {
+	interval := time.Until(job.LockedUntil) / 2
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.queue.Extend(ctx, job); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func run(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}